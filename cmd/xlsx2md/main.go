@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"excellent-md/pkg/xlsxmd"
+)
+
+// Exit codes returned by the CLI. When several inputs fail, the first
+// failure determines the exit code.
+const (
+	exitOK            = 0
+	exitFailure       = 1
+	exitUsage         = 2
+	exitInvalidFile   = 3
+	exitTooManySheets = 4
+	exitSheetTooLarge = 5
+	exitTimeout       = 6
+)
+
+const stdinName = "stdin"
+
+// errSingleTable is returned when CSV or TSV output would need more than
+// one table; it maps to exitUsage since other flags avoid it.
+var errSingleTable = errors.New("csv and tsv output hold a single table")
+
+type cliOptions struct {
+	outDir      string
+	timeout     time.Duration
+	columnAlign string
+	values      string
+	formulas    string
+	merges      string
+	comments    string
+	header      string
+	hidden      string
+	outline     string
+	sheets      string
+	columns     string
+	excludeCols string
+	unzipMB     int64
+	unzipXMLMB  int64
+	convert     xlsxmd.Options
+}
+
+type input struct {
+	name string
+	path string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts, paths, err := parseFlags(args, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	inputs, err := expandInputs(paths)
+	if err != nil {
+		fmt.Fprintf(stderr, "xlsx2md: %v\n", err)
+		return exitUsage
+	}
+
+	if singleTable(opts.convert.Format) && opts.outDir == "" && len(inputs) > 1 {
+		fmt.Fprintf(stderr, "xlsx2md: %v; convert one workbook at a time or use -out\n", errSingleTable)
+		return exitUsage
+	}
+
+	code := exitOK
+	combined := []string{}
+	dirs := outputDirs(inputs)
+	for i, in := range inputs {
+		result, err := convertInput(in, stdin, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "xlsx2md: %s: %v\n", in.name, err)
+			code = firstFailure(code, exitCode(err))
+			continue
+		}
+		for _, sheet := range result.Sheets {
+			if sheet.Err != nil {
+				fmt.Fprintf(stderr, "xlsx2md: %s: sheet %q: %v\n", in.name, sheet.Name, sheet.Err)
+				code = firstFailure(code, exitCode(sheet.Err))
+			}
+		}
+
+		if opts.outDir != "" {
+			if err := writeSheetFiles(filepath.Join(opts.outDir, dirs[i]), result); err != nil {
+				fmt.Fprintf(stderr, "xlsx2md: %s: %v\n", in.name, err)
+				code = firstFailure(code, exitCode(err))
+			}
+			continue
+		}
+		if singleTable(result.Format) && (len(result.Sheets) != 1 || len(result.Sheets[0].Tables) > 1) {
+			fmt.Fprintf(stderr, "xlsx2md: %s: %v; select one sheet with -sheets or use -out\n", in.name, errSingleTable)
+			code = firstFailure(code, exitUsage)
+			continue
+		}
+
+		if len(inputs) > 1 {
+			combined = append(combined, "# "+in.name, "")
+		}
+		combined = append(combined, combinedOutput(result))
+	}
+
+	if opts.outDir == "" && len(combined) > 0 {
+		if _, err := io.WriteString(stdout, strings.Join(combined, "\n")); err != nil {
+			fmt.Fprintf(stderr, "xlsx2md: write output: %v\n", err)
+			code = firstFailure(code, exitFailure)
+		}
+	}
+
+	return code
+}
+
+func parseFlags(args []string, stderr io.Writer) (cliOptions, []string, error) {
+	opts := cliOptions{}
+	flags := flag.NewFlagSet("xlsx2md", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: xlsx2md [flags] [file.xlsx | glob | -]...")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Converts XLSX workbooks to Markdown. Reads stdin when no inputs are given or for \"-\".")
+		fmt.Fprintln(stderr, "")
+		flags.PrintDefaults()
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Exit codes: 0 ok, 1 failure, 2 usage, 3 invalid file, 4 too many sheets, 5 sheet too large, 6 timeout.")
+	}
+
+	flags.StringVar(&opts.outDir, "out", "", "write one Markdown file per sheet under `dir` instead of printing to stdout")
+	flags.DurationVar(&opts.timeout, "timeout", 0, "per-workbook conversion timeout (0 disables)")
+	flags.StringVar(&opts.convert.Format, "format", xlsxmd.FormatMarkdown, "output format: "+strings.Join(xlsxmd.Formats(), ", "))
+	flags.BoolVar(&opts.convert.AlignColumns, "align", false, "right-align numeric and left-align text columns")
+	flags.StringVar(&opts.columnAlign, "column-align", "", "force column alignment, e.g. `Price=right,B=center`")
+	flags.StringVar(&opts.values, "values", string(xlsxmd.ValuesFormatted), "cell values: formatted (apply number formats) or raw")
+	flags.StringVar(&opts.formulas, "formulas", string(xlsxmd.FormulaCached), "formula output: cached, formula, both or recalculate")
+	flags.StringVar(&opts.merges, "merges", string(xlsxmd.MergeTopLeft), "merged cells: top-left, fill or html")
+	flags.BoolVar(&opts.convert.RichText, "rich-text", false, "map bold, italic, strikethrough and monospace fonts to inline Markdown")
+	flags.BoolVar(&opts.convert.Hyperlinks, "hyperlinks", false, "output linked cells as Markdown links")
+	flags.StringVar(&opts.comments, "comments", string(xlsxmd.CommentNone), "cell comments: none, footnotes or notes")
+	flags.StringVar(&opts.header, "header", string(xlsxmd.HeaderFirstRow), "header row: first-row, auto, row:N, rows:N-M or none")
+	flags.BoolVar(&opts.convert.DetectTables, "detect-tables", false, "split sheets into one table per block separated by blank rows or columns")
+	flags.BoolVar(&opts.convert.NamedObjects, "named-objects", false, "convert only Excel Tables and defined names")
+	flags.StringVar(&opts.outline, "outline", string(xlsxmd.OutlineNone), "grouped rows: none, indent, arrow or list")
+	flags.StringVar(&opts.sheets, "sheets", "", "convert only these sheets: comma-separated names, glob patterns or 1-based positions")
+	flags.Func("range", "convert only this A1 `range`, e.g. B3:F20 or Sales!A:D; repeat for one range per sheet", func(ref string) error {
+		opts.convert.Ranges = append(opts.convert.Ranges, ref)
+		return nil
+	})
+	flags.StringVar(&opts.columns, "columns", "", "keep only these comma-separated columns (header names or letters)")
+	flags.StringVar(&opts.excludeCols, "exclude-columns", "", "drop these comma-separated columns (header names or letters)")
+	flags.StringVar(&opts.convert.Filter, "filter", "", "keep rows matching an `expression`, e.g. 'Status = \"Open\" and Priority <= 2'")
+	flags.StringVar(&opts.convert.Sort, "sort", "", "sort rows by comma-separated columns, - for descending, e.g. -Updated")
+	flags.BoolVar(&opts.convert.IncludeHiddenSheets, "include-hidden", false, "include hidden sheets")
+	flags.StringVar(&opts.hidden, "hidden-rows-cols", string(xlsxmd.HiddenInclude), "hidden rows and columns: include, exclude or mark")
+	flags.IntVar(&opts.convert.MaxSheets, "max-sheets", 0, "maximum sheets per workbook (0 disables)")
+	flags.IntVar(&opts.convert.MaxCellsPerSheet, "max-cells", 0, "maximum cells per sheet (0 disables)")
+	flags.IntVar(&opts.convert.Concurrency, "concurrency", runtime.NumCPU(), "sheets converted in parallel")
+	flags.Int64Var(&opts.unzipMB, "max-unzip-mb", 0, "maximum size in MB a workbook may inflate to when opened (0 keeps the 16 GB default)")
+	flags.Int64Var(&opts.unzipXMLMB, "unzip-xml-mb", 0, "worksheet size in MB above which sheets are unzipped to temporary files (0 keeps the 16 MB default)")
+	flags.StringVar(&opts.convert.TempDir, "temp-dir", "", "directory for unzipped worksheets (default the system temporary directory)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	opts.convert.Values = xlsxmd.ValueMode(opts.values)
+	opts.convert.FormulaMode = xlsxmd.FormulaMode(opts.formulas)
+	opts.convert.MergeStrategy = xlsxmd.MergeStrategy(opts.merges)
+	opts.convert.Comments = xlsxmd.CommentMode(opts.comments)
+	opts.convert.HeaderMode = xlsxmd.HeaderMode(opts.header)
+	opts.convert.HiddenRowsCols = xlsxmd.HiddenMode(opts.hidden)
+	opts.convert.Outline = xlsxmd.OutlineMode(opts.outline)
+	opts.convert.Sheets = splitList(opts.sheets)
+	opts.convert.Columns = splitList(opts.columns)
+	opts.convert.ExcludeColumns = splitList(opts.excludeCols)
+	opts.convert.UnzipSizeLimit = opts.unzipMB << 20
+	opts.convert.UnzipXMLSizeLimit = opts.unzipXMLMB << 20
+	if opts.columnAlign != "" {
+		aligns, err := xlsxmd.ParseColumnAlignments(opts.columnAlign)
+		if err != nil {
+			fmt.Fprintf(stderr, "xlsx2md: %v\n", err)
+			return opts, nil, err
+		}
+		opts.convert.ColumnAlignments = aligns
+	}
+	if err := opts.convert.Validate(); err != nil {
+		fmt.Fprintf(stderr, "xlsx2md: %v\n", err)
+		return opts, nil, err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	return opts, paths, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// expandInputs resolves glob patterns and keeps plain paths and "-" as given.
+// stdin can only be read once, so "-" may appear only once.
+func expandInputs(paths []string) ([]input, error) {
+	inputs := []input{}
+	stdin := false
+	for _, path := range paths {
+		if path == "-" {
+			if stdin {
+				return nil, errors.New(`"-" (stdin) may be given only once`)
+			}
+			stdin = true
+			inputs = append(inputs, input{name: stdinName, path: path})
+			continue
+		}
+		if !strings.ContainsAny(path, "*?[") {
+			inputs = append(inputs, input{name: workbookName(path), path: path})
+			continue
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", path)
+		}
+		for _, match := range matches {
+			inputs = append(inputs, input{name: workbookName(match), path: match})
+		}
+	}
+	return inputs, nil
+}
+
+func convertInput(in input, stdin io.Reader, opts cliOptions) (xlsxmd.Result, error) {
+	var payload []byte
+	var err error
+	if in.path == "-" {
+		payload, err = io.ReadAll(stdin)
+	} else {
+		payload, err = os.ReadFile(in.path)
+	}
+	if err != nil {
+		return xlsxmd.Result{}, fmt.Errorf("read input: %w", err)
+	}
+
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	return xlsxmd.Convert(ctx, payload, xlsxmd.WithOptions(opts.convert))
+}
+
+// outputDirs returns the directory under -out for each input: its safe file
+// name, with a -2, -3 suffix when inputs share a base name, as a/book.xlsx
+// and b/book.xlsx do. Names are compared case-insensitively so they stay
+// apart on file systems that ignore case.
+func outputDirs(inputs []input) []string {
+	used := map[string]bool{}
+	dirs := make([]string, len(inputs))
+	for i, in := range inputs {
+		base := safeFileName(in.name)
+		dir := base
+		for n := 2; used[strings.ToLower(dir)]; n++ {
+			dir = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[strings.ToLower(dir)] = true
+		dirs[i] = dir
+	}
+	return dirs
+}
+
+// writeSheetFiles writes one file per sheet to dir. CSV and TSV sheets split
+// into several tables are rejected before anything is written.
+func writeSheetFiles(dir string, result xlsxmd.Result) error {
+	for _, sheet := range result.Sheets {
+		if singleTable(result.Format) && len(sheet.Tables) > 1 {
+			return fmt.Errorf("sheet %q holds %d tables: %w", sheet.Name, len(sheet.Tables), errSingleTable)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
+	names := xlsxmd.SheetFileNames(result.Sheets)
+	for i, sheet := range result.Sheets {
+		name := names[i]
+		content := xlsxmd.CombineMarkdown([]xlsxmd.SheetResult{sheet})
+		if result.Format != "" {
+			content = sheet.Output
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+fileExtension(result.Format)), []byte(content), 0o644); err != nil {
+			return fmt.Errorf("write sheet %q: %w", sheet.Name, err)
+		}
+	}
+	return nil
+}
+
+func combinedOutput(result xlsxmd.Result) string {
+	if result.Format != "" {
+		return result.CombinedOutput
+	}
+	return result.CombinedMarkdown
+}
+
+// singleTable reports whether format holds a single table, as CSV and TSV
+// do, so several sheets or tables cannot be combined into one document.
+func singleTable(format string) bool {
+	format = strings.ToLower(strings.TrimSpace(format))
+	return format == xlsxmd.FormatCSV || format == xlsxmd.FormatTSV
+}
+
+func fileExtension(format string) string {
+	switch format {
+	case xlsxmd.FormatCSV, xlsxmd.FormatTSV, xlsxmd.FormatJSON, xlsxmd.FormatJSONL, xlsxmd.FormatHTML:
+		return "." + format
+	case xlsxmd.FormatAsciiDoc, "adoc":
+		return ".adoc"
+	case "ndjson":
+		return ".jsonl"
+	default:
+		return ".md"
+	}
+}
+
+func workbookName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// safeFileName replaces characters that are awkward in file names.
+func safeFileName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if cleaned == "" || cleaned == "." || cleaned == ".." {
+		return "sheet"
+	}
+	return cleaned
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errSingleTable):
+		return exitUsage
+	case errors.Is(err, xlsxmd.ErrTooManySheets):
+		return exitTooManySheets
+	case errors.Is(err, xlsxmd.ErrSheetTooLarge):
+		return exitSheetTooLarge
+	case errors.Is(err, xlsxmd.ErrConversionTimeout):
+		return exitTimeout
+	case errors.Is(err, xlsxmd.ErrInvalidFile):
+		return exitInvalidFile
+	default:
+		return exitFailure
+	}
+}
+
+func firstFailure(current, next int) int {
+	if current != exitOK {
+		return current
+	}
+	return next
+}
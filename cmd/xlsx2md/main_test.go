@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeWorkbook saves a workbook with the given sheets, each holding a
+// header and two data rows, to path.
+func writeWorkbook(t *testing.T, path string, sheets ...string) {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	for i, sheet := range sheets {
+		if i == 0 {
+			file.SetSheetName("Sheet1", sheet)
+		} else if _, err := file.NewSheet(sheet); err != nil {
+			t.Fatalf("failed to build xlsx: %v", err)
+		}
+		file.SetSheetRow(sheet, "A1", &[]any{"Name", "Qty"})
+		file.SetSheetRow(sheet, "A2", &[]any{"Asha", 1})
+		file.SetSheetRow(sheet, "A3", &[]any{"Ben", 2})
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := file.SaveAs(path); err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	book := filepath.Join(dir, "book.xlsx")
+	writeWorkbook(t, book, "Sales", "Costs", "Notes")
+	broken := filepath.Join(dir, "broken.xlsx")
+	if err := os.WriteFile(broken, []byte("not a workbook"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"ok", []string{book}, exitOK},
+		{"usage", []string{"-values", "fancy", book}, exitUsage},
+		{"no matches", []string{filepath.Join(dir, "*.xls")}, exitUsage},
+		{"invalid file", []string{broken}, exitInvalidFile},
+		{"missing file", []string{filepath.Join(dir, "missing.xlsx")}, exitFailure},
+		{"too many sheets", []string{"-max-sheets", "2", book}, exitTooManySheets},
+		{"sheet too large", []string{"-max-cells", "3", book}, exitSheetTooLarge},
+		{"timeout", []string{"-timeout", "1ns", book}, exitTimeout},
+		{"csv of several sheets", []string{"-format", "csv", book}, exitUsage},
+		{"csv of several workbooks", []string{"-format", "csv", "-sheets", "Sales", book, book}, exitUsage},
+		{"first failure wins", []string{"-max-sheets", "2", broken, book}, exitInvalidFile},
+		{"stdin twice", []string{"-", book, "-"}, exitUsage},
+		{"unzip limits", []string{"-max-unzip-mb", "1", "-unzip-xml-mb", "1", "-temp-dir", dir, book}, exitOK},
+		{"negative unzip limit", []string{"-max-unzip-mb", "-1", book}, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, strings.NewReader(""), &stdout, &stderr); code != tt.code {
+				t.Fatalf("got exit code %d, expected %d; stderr:\n%s", code, tt.code, stderr.String())
+			}
+			if tt.code != exitOK && stderr.Len() == 0 {
+				t.Fatalf("expected an error message on stderr")
+			}
+		})
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	writeWorkbook(t, path, "Sales")
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read workbook: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, bytes.NewReader(payload), &stdout, &stderr); code != exitOK {
+		t.Fatalf("got exit code %d; stderr:\n%s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "## Sales\n") || !strings.Contains(stdout.String(), "| Asha | 1 |") {
+		t.Fatalf("unexpected output:\n%s", stdout.String())
+	}
+}
+
+func TestRunCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	writeWorkbook(t, path, "Sales", "Costs")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-format", "csv", "-sheets", "Costs", path}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("got exit code %d; stderr:\n%s", code, stderr.String())
+	}
+	records, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "Name" {
+		t.Fatalf("unexpected records: %q", records)
+	}
+
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	file.SetSheetRow("Sales", "A6", &[]any{"Region", "Total"})
+	file.SetSheetRow("Sales", "A7", &[]any{"EU", 3})
+	if err := file.Save(); err != nil {
+		t.Fatalf("failed to save workbook: %v", err)
+	}
+	file.Close()
+
+	out := filepath.Join(t.TempDir(), "out")
+	args := []string{"-format", "csv", "-detect-tables", "-out", out, path}
+	if code := run(args, strings.NewReader(""), &stdout, &stderr); code != exitUsage {
+		t.Fatalf("got exit code %d, expected %d; stderr:\n%s", code, exitUsage, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(out, "book", "costs.csv")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be written, got %v", err)
+	}
+}
+
+func TestRunOutDirSeparatesSameNames(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkbook(t, filepath.Join(dir, "a", "book.xlsx"), "Sales")
+	writeWorkbook(t, filepath.Join(dir, "b", "book.xlsx"), "Costs")
+	writeWorkbook(t, filepath.Join(dir, "c", "Book.xlsx"), "Notes")
+	out := filepath.Join(dir, "out")
+
+	args := []string{"-out", out, filepath.Join(dir, "a", "book.xlsx"), filepath.Join(dir, "b", "book.xlsx"), filepath.Join(dir, "c", "Book.xlsx")}
+	var stdout, stderr bytes.Buffer
+	if code := run(args, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("got exit code %d; stderr:\n%s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected no output on stdout, got:\n%s", stdout.String())
+	}
+	for file, heading := range map[string]string{
+		"book/sales.md":   "## Sales",
+		"book-2/costs.md": "## Costs",
+		"Book-3/notes.md": "## Notes",
+	} {
+		data, err := os.ReadFile(filepath.Join(out, file))
+		if err != nil {
+			t.Fatalf("expected %s: %v", file, err)
+		}
+		if !strings.HasPrefix(string(data), heading+"\n") {
+			t.Fatalf("unexpected %s:\n%s", file, data)
+		}
+	}
+}
@@ -2,15 +2,26 @@ package config
 
 import (
 	"os"
+	"runtime"
 	"strconv"
 	"time"
 )
 
 const (
 	defaultMaxUploadMB       = 50
+	defaultMaxStreamUploadMB = 500
 	defaultMaxSheets         = 50
 	defaultMaxCellsPerSheet  = 200000
+	defaultMaxBatchFiles     = 20
+	defaultMaxZipEntries     = 200
+	defaultMaxZipMB          = 200
 	defaultTimeoutSeconds    = 10
+	defaultStreamTimeout     = 300
+	defaultJobWorkers        = 2
+	defaultJobQueueSize      = 100
+	defaultJobQueueMB        = 200
+	defaultJobTimeoutSeconds = 300
+	defaultJobTTLSeconds     = 3600
 	defaultIncludeHidden     = false
 	defaultAddr              = ":8080"
 	defaultDBMaxOpenConns    = 5
@@ -22,26 +33,48 @@ const (
 
 // Config defines runtime limits and behavior.
 type Config struct {
-	Addr                string
-	MaxUploadBytes      int64
-	MaxSheets           int
-	MaxCellsPerSheet    int
-	ConversionTimeout   time.Duration
-	IncludeHiddenSheets bool
-	DatabaseURL         string
-	DBMaxOpenConns      int
-	DBMaxIdleConns      int
-	DBConnMaxLifetime   time.Duration
-	DBConnMaxIdleTime   time.Duration
-	EnableDebugVars     bool
+	Addr                 string
+	MaxUploadBytes       int64
+	MaxStreamUploadBytes int64
+	MaxSheets            int
+	MaxCellsPerSheet     int
+	MaxBatchFiles        int
+	MaxZipEntries        int
+	MaxZipBytes          int64
+	SheetConcurrency     int
+	ConversionTimeout    time.Duration
+	StreamTimeout        time.Duration
+	JobWorkers           int
+	JobQueueSize         int
+	MaxQueuedJobBytes    int64
+	JobTimeout           time.Duration
+	JobTTL               time.Duration
+	IncludeHiddenSheets  bool
+	DatabaseURL          string
+	DBMaxOpenConns       int
+	DBMaxIdleConns       int
+	DBConnMaxLifetime    time.Duration
+	DBConnMaxIdleTime    time.Duration
+	EnableDebugVars      bool
 }
 
 // Load reads environment variables and returns a populated config.
 func Load() Config {
 	maxUploadMB := getEnvInt("MAX_UPLOAD_MB", defaultMaxUploadMB)
+	maxStreamUploadMB := getEnvInt("MAX_STREAM_UPLOAD_MB", defaultMaxStreamUploadMB)
 	maxSheets := getEnvInt("MAX_SHEETS", defaultMaxSheets)
 	maxCells := getEnvInt("MAX_CELLS_PER_SHEET", defaultMaxCellsPerSheet)
+	maxBatchFiles := getEnvInt("MAX_BATCH_FILES", defaultMaxBatchFiles)
+	maxZipEntries := getEnvInt("MAX_ZIP_ENTRIES", defaultMaxZipEntries)
+	maxZipMB := getEnvInt("MAX_ZIP_UNCOMPRESSED_MB", defaultMaxZipMB)
+	sheetConcurrency := getEnvInt("SHEET_CONCURRENCY", runtime.NumCPU())
 	timeoutSeconds := getEnvInt("CONVERSION_TIMEOUT_SECONDS", defaultTimeoutSeconds)
+	streamTimeoutSeconds := getEnvInt("STREAM_TIMEOUT_SECONDS", defaultStreamTimeout)
+	jobWorkers := getEnvInt("JOB_WORKERS", defaultJobWorkers)
+	jobQueueSize := getEnvInt("JOB_QUEUE_SIZE", defaultJobQueueSize)
+	jobQueueMB := getEnvInt("JOB_QUEUE_MAX_MB", defaultJobQueueMB)
+	jobTimeoutSeconds := getEnvInt("JOB_TIMEOUT_SECONDS", defaultJobTimeoutSeconds)
+	jobTTLSeconds := getEnvInt("JOB_TTL_SECONDS", defaultJobTTLSeconds)
 	includeHidden := getEnvBool("INCLUDE_HIDDEN_SHEETS", defaultIncludeHidden)
 	addr := getEnvString("ADDR", defaultAddr)
 	databaseURL := getEnvString("DATABASE_URL", "")
@@ -52,18 +85,29 @@ func Load() Config {
 	enableDebug := getEnvBool("ENABLE_DEBUG_VARS", defaultEnableDebugVars)
 
 	return Config{
-		Addr:                addr,
-		MaxUploadBytes:      int64(maxUploadMB) << 20,
-		MaxSheets:           maxSheets,
-		MaxCellsPerSheet:    maxCells,
-		ConversionTimeout:   time.Duration(timeoutSeconds) * time.Second,
-		IncludeHiddenSheets: includeHidden,
-		DatabaseURL:         databaseURL,
-		DBMaxOpenConns:      dbMaxOpen,
-		DBMaxIdleConns:      dbMaxIdle,
-		DBConnMaxLifetime:   time.Duration(dbConnMaxLifetime) * time.Second,
-		DBConnMaxIdleTime:   time.Duration(dbConnMaxIdleTime) * time.Second,
-		EnableDebugVars:     enableDebug,
+		Addr:                 addr,
+		MaxUploadBytes:       int64(maxUploadMB) << 20,
+		MaxStreamUploadBytes: int64(maxStreamUploadMB) << 20,
+		MaxSheets:            maxSheets,
+		MaxCellsPerSheet:     maxCells,
+		MaxBatchFiles:        maxBatchFiles,
+		MaxZipEntries:        maxZipEntries,
+		MaxZipBytes:          int64(maxZipMB) << 20,
+		SheetConcurrency:     sheetConcurrency,
+		ConversionTimeout:    time.Duration(timeoutSeconds) * time.Second,
+		StreamTimeout:        time.Duration(streamTimeoutSeconds) * time.Second,
+		JobWorkers:           jobWorkers,
+		JobQueueSize:         jobQueueSize,
+		MaxQueuedJobBytes:    int64(jobQueueMB) << 20,
+		JobTimeout:           time.Duration(jobTimeoutSeconds) * time.Second,
+		JobTTL:               time.Duration(jobTTLSeconds) * time.Second,
+		IncludeHiddenSheets:  includeHidden,
+		DatabaseURL:          databaseURL,
+		DBMaxOpenConns:       dbMaxOpen,
+		DBMaxIdleConns:       dbMaxIdle,
+		DBConnMaxLifetime:    time.Duration(dbConnMaxLifetime) * time.Second,
+		DBConnMaxIdleTime:    time.Duration(dbConnMaxIdleTime) * time.Second,
+		EnableDebugVars:      enableDebug,
 	}
 }
 
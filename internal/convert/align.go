@@ -0,0 +1,244 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Alignment is a Markdown table column alignment.
+type Alignment string
+
+const (
+	AlignNone   Alignment = ""
+	AlignLeft   Alignment = "left"
+	AlignRight  Alignment = "right"
+	AlignCenter Alignment = "center"
+)
+
+// ColumnType is the detected kind of values in a column.
+type ColumnType string
+
+const (
+	ColumnEmpty      ColumnType = "empty"
+	ColumnNumeric    ColumnType = "numeric"
+	ColumnPercentage ColumnType = "percentage"
+	ColumnCurrency   ColumnType = "currency"
+	ColumnDate       ColumnType = "date"
+	ColumnText       ColumnType = "text"
+)
+
+var currencySymbols = []string{"$", "€", "£", "¥", "₹", "USD", "EUR", "GBP"}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006/01/02",
+	"01/02/2006",
+	"1/2/2006",
+	"01-02-06",
+	"1-2-06",
+	"02-Jan-06",
+	"2-Jan-2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+}
+
+// DetectColumnTypes classifies each column from the data rows (every row
+// after the header). Empty cells are ignored; a column mixing kinds of
+// numbers is numeric, and any other mix is text.
+func DetectColumnTypes(rows [][]string) []ColumnType {
+	normalized, width := normalizeRows(rows)
+	types := make([]ColumnType, width)
+	for col := range types {
+		types[col] = ColumnEmpty
+	}
+	if len(normalized) < 2 {
+		return types
+	}
+
+	for col := range types {
+		for _, row := range normalized[1:] {
+			cellType := detectCellType(row[col])
+			if cellType == ColumnEmpty {
+				continue
+			}
+			types[col] = mergeColumnTypes(types[col], cellType)
+			if types[col] == ColumnText {
+				break
+			}
+		}
+	}
+	return types
+}
+
+func mergeColumnTypes(current, next ColumnType) ColumnType {
+	switch {
+	case current == ColumnEmpty || current == next:
+		return next
+	case isNumericType(current) && isNumericType(next):
+		return ColumnNumeric
+	default:
+		return ColumnText
+	}
+}
+
+func isNumericType(columnType ColumnType) bool {
+	return columnType == ColumnNumeric || columnType == ColumnPercentage || columnType == ColumnCurrency
+}
+
+func detectCellType(value string) ColumnType {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ColumnEmpty
+	}
+	if isNumber(value) {
+		return ColumnNumeric
+	}
+	if strings.HasSuffix(value, "%") && isNumber(strings.TrimSuffix(value, "%")) {
+		return ColumnPercentage
+	}
+	if isCurrency(value) {
+		return ColumnCurrency
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return ColumnDate
+		}
+	}
+	return ColumnText
+}
+
+// isNumber accepts plain numbers, thousands separators and accounting
+// negatives such as "(1,200.50)".
+func isNumber(value string) bool {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = value[1 : len(value)-1]
+	}
+	if value == "" {
+		return false
+	}
+	if strings.Contains(value, ",") {
+		whole := strings.SplitN(strings.TrimLeft(value, "+-"), ".", 2)[0]
+		groups := strings.Split(whole, ",")
+		for i, group := range groups {
+			if (i == 0 && (len(group) == 0 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+				return false
+			}
+		}
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func isCurrency(value string) bool {
+	trimmed := strings.TrimSpace(value)
+	sign := ""
+	if strings.HasPrefix(trimmed, "-") {
+		sign = "-"
+		trimmed = trimmed[1:]
+	}
+	for _, symbol := range currencySymbols {
+		if rest, ok := strings.CutPrefix(trimmed, symbol); ok {
+			return isNumber(sign + strings.TrimSpace(rest))
+		}
+		if rest, ok := strings.CutSuffix(trimmed, symbol); ok {
+			return isNumber(sign + strings.TrimSpace(rest))
+		}
+	}
+	return false
+}
+
+// columnAlignments resolves the alignment of every column. Forced entries
+// are matched by header text first and column letter second, and win over
+// detection; detection only runs when auto is set. header is the header
+// text without markup and colNum maps a column index to its 1-based sheet
+// column; nil uses rows[0] and the column position.
+func columnAlignments(rows [][]string, auto bool, forced map[string]Alignment, header []string, colNum func(int) int) []Alignment {
+	normalized, width := normalizeRows(rows)
+	if width == 0 || (!auto && len(forced) == 0) {
+		return nil
+	}
+
+	aligns := make([]Alignment, width)
+	if auto {
+		for col, columnType := range DetectColumnTypes(normalized) {
+			switch {
+			case isNumericType(columnType):
+				aligns[col] = AlignRight
+			case columnType == ColumnText || columnType == ColumnDate:
+				aligns[col] = AlignLeft
+			}
+		}
+	}
+
+	if len(forced) > 0 {
+		byKey := map[string]Alignment{}
+		for key, align := range forced {
+			byKey[strings.ToLower(strings.TrimSpace(key))] = align
+		}
+		if header == nil {
+			header = normalized[0]
+		}
+		for col := range aligns {
+			name := ""
+			if col < len(header) {
+				name = strings.ToLower(strings.TrimSpace(header[col]))
+			}
+			number := col + 1
+			if colNum != nil {
+				number = colNum(col)
+			}
+			letter, _ := excelize.ColumnNumberToName(number)
+			if align, ok := byKey[name]; ok && name != "" {
+				aligns[col] = align
+			} else if align, ok := byKey[strings.ToLower(letter)]; ok {
+				aligns[col] = align
+			}
+		}
+	}
+	return aligns
+}
+
+// ParseAlignment accepts left, right, center and none (or l, r, c).
+func ParseAlignment(value string) (Alignment, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "left", "l":
+		return AlignLeft, nil
+	case "right", "r":
+		return AlignRight, nil
+	case "center", "centre", "c":
+		return AlignCenter, nil
+	case "none", "":
+		return AlignNone, nil
+	default:
+		return AlignNone, fmt.Errorf("unknown alignment %q", value)
+	}
+}
+
+// ParseColumnAlignments parses "Price=right,B=center" into a column
+// alignment map. Keys are header names or column letters.
+func ParseColumnAlignments(spec string) (map[string]Alignment, error) {
+	aligns := map[string]Alignment{}
+	for _, part := range strings.Split(spec, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid column alignment %q, expected column=alignment", part)
+		}
+		align, err := ParseAlignment(value)
+		if err != nil {
+			return nil, err
+		}
+		aligns[strings.TrimSpace(key)] = align
+	}
+	return aligns, nil
+}
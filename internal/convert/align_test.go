@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectColumnTypes(t *testing.T) {
+	rows := [][]string{
+		{"Item", "Qty", "Share", "Price", "Due", "Mixed", "Blank"},
+		{"Widget", "1,200", "15.3%", "$19.99", "2023-07-18", "12", ""},
+		{"Gadget", "(35)", "2%", "-€5", "2023-08-01", "n/a"},
+	}
+	expected := []ColumnType{ColumnText, ColumnNumeric, ColumnPercentage, ColumnCurrency, ColumnDate, ColumnText, ColumnEmpty}
+	if got := DetectColumnTypes(rows); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("unexpected column types: %v", got)
+	}
+}
+
+func TestColumnAlignments(t *testing.T) {
+	rows := [][]string{
+		{"Name", "Amount", "Code"},
+		{"Asha", "10", "7"},
+	}
+	markdown, _, _ := SheetToMarkdownAligned(rows, columnAlignments(rows, true, map[string]Alignment{"c": AlignCenter}, nil, nil))
+	expected := "| Name | Amount | Code |\n| :--- | ---: | :---: |\n| Asha | 10 | 7 |"
+	if markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+
+	forced := map[string]Alignment{"price": AlignRight, "D": AlignCenter}
+	aligns := columnAlignments([][]string{{"**Price**", "Qty"}}, false, forced, []string{"Price", "Qty"}, func(col int) int { return col + 3 })
+	if !reflect.DeepEqual(aligns, []Alignment{AlignRight, AlignCenter}) {
+		t.Fatalf("expected alignments by plain header and sheet column, got %v", aligns)
+	}
+
+	if aligns := columnAlignments(rows, false, nil, nil, nil); aligns != nil {
+		t.Fatalf("expected no alignment without options, got %v", aligns)
+	}
+}
+
+func TestParseColumnAlignments(t *testing.T) {
+	aligns, err := ParseColumnAlignments("Price=right, B = c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aligns["Price"] != AlignRight || aligns["B"] != AlignCenter {
+		t.Fatalf("unexpected alignments: %v", aligns)
+	}
+	if _, err := ParseColumnAlignments("Price"); err == nil {
+		t.Fatalf("expected error for missing alignment")
+	}
+}
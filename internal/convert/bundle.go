@@ -0,0 +1,131 @@
+package convert
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Slug returns a lowercase file-name-safe form of name: letters and digits
+// are kept and every other run of characters becomes a single hyphen.
+// Names without letters or digits become "sheet".
+func Slug(name string) string {
+	var b strings.Builder
+	pending := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pending && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pending = false
+			b.WriteRune(r)
+			continue
+		}
+		pending = true
+	}
+	if b.Len() == 0 {
+		return "sheet"
+	}
+	return b.String()
+}
+
+// SheetFileNames returns a unique slug per sheet, in order, for naming one
+// file per sheet. Repeated slugs get a -2, -3 suffix, and slugs in reserved
+// are never returned.
+func SheetFileNames(sheets []SheetResult, reserved ...string) []string {
+	used := map[string]bool{}
+	for _, name := range reserved {
+		used[name] = true
+	}
+	names := make([]string, len(sheets))
+	for i, sheet := range sheets {
+		slug := Slug(sheet.Name)
+		name := slug
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", slug, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// bundleManifest is manifest.json of a bundle.
+type bundleManifest struct {
+	Meta    Meta           `json:"meta"`
+	Sheets  []bundleSheet  `json:"sheets"`
+	Skipped []SkippedSheet `json:"skipped"`
+}
+
+type bundleSheet struct {
+	Name     string   `json:"name"`
+	File     string   `json:"file"`
+	RowCount int      `json:"row_count"`
+	ColCount int      `json:"col_count"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// WriteBundle writes result as a ZIP archive holding one Markdown file per
+// sheet named by SheetFileNames, a README.md with a table of contents
+// followed by the combined document, and a manifest.json listing Meta, the
+// sheets with their files and warnings, and the skipped sheets. Links to
+// sheet headings, such as "#q1-sales", point into the sheet's file in the
+// sheet files, e.g. "q1-sales.md#q1-sales".
+func WriteBundle(w io.Writer, result Result) error {
+	names := SheetFileNames(result.Sheets, "readme", "manifest")
+	links := []string{}
+	for i, sheet := range result.Sheets {
+		anchor := headingAnchor(sheet.Name)
+		links = append(links, "](#"+anchor+")", "]("+names[i]+".md#"+anchor+")")
+	}
+	sheetLinks := strings.NewReplacer(links...)
+	manifest := bundleManifest{Meta: result.Meta, Sheets: []bundleSheet{}, Skipped: result.Skipped}
+	if manifest.Skipped == nil {
+		manifest.Skipped = []SkippedSheet{}
+	}
+	contents := []string{"# Contents", ""}
+
+	archive := zip.NewWriter(w)
+	for i, sheet := range result.Sheets {
+		file := names[i] + ".md"
+		if err := writeBundleFile(archive, file, result.Meta.GeneratedAt, []byte(sheetLinks.Replace(CombineMarkdown([]SheetResult{sheet})))); err != nil {
+			return err
+		}
+		contents = append(contents, "- "+markdownLink(escapeInline(sheet.Name), file))
+		manifest.Sheets = append(manifest.Sheets, bundleSheet{
+			Name:     sheet.Name,
+			File:     file,
+			RowCount: sheet.RowCount,
+			ColCount: sheet.ColCount,
+			Warnings: sheet.Warnings,
+			Error:    sheet.Error,
+		})
+	}
+
+	readme := strings.Join(contents, "\n") + "\n\n" + CombineMarkdown(result.Sheets)
+	if err := writeBundleFile(archive, "README.md", result.Meta.GeneratedAt, []byte(readme)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeBundleFile(archive, "manifest.json", result.Meta.GeneratedAt, append(data, '\n')); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeBundleFile(archive *zip.Writer, name string, modified time.Time, data []byte) error {
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return err
+}
@@ -0,0 +1,98 @@
+package convert
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSheetFileNames(t *testing.T) {
+	sheets := []SheetResult{
+		{Name: "Q1 Sales (EU)"},
+		{Name: "q1 sales / eu"},
+		{Name: "Q1-Sales-EU-2"},
+		{Name: "Übersicht"},
+		{Name: "***"},
+		{Name: "README"},
+	}
+	got := SheetFileNames(sheets, "readme")
+	expected := []string{"q1-sales-eu", "q1-sales-eu-2", "q1-sales-eu-2-2", "übersicht", "sheet", "readme-2"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	result := Result{
+		Sheets: []SheetResult{
+			{Name: "Sales [EU]", Markdown: "| A |\n| --- |\n| 1 |", RowCount: 2, ColCount: 1, Warnings: []string{"Merged cells were flattened: A1:B1."}},
+			{Name: "Broken", Error: "sheet exceeds cell limit"},
+			{Name: "Q1 Sales", Markdown: "| Go |\n| --- |\n| [Broken](#broken) |\n| [Top](#q1-sales) |\n| [Site](https://example.com/#broken) |", RowCount: 3, ColCount: 1},
+		},
+		Skipped: []SkippedSheet{{Name: "Secret", Reason: "hidden"}},
+		Meta:    Meta{SheetCount: 3, Processed: 2, SkippedCount: 1},
+	}
+	result.CombinedMarkdown = CombineMarkdown(result.Sheets)
+
+	var out bytes.Buffer
+	if err := WriteBundle(&out, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := map[string]string{}
+	names := []string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		files[file.Name] = string(data)
+		names = append(names, file.Name)
+	}
+
+	if expected := []string{"sales-eu.md", "broken.md", "q1-sales.md", "README.md", "manifest.json"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("got files %q, expected %q", names, expected)
+	}
+	if expected := "| [Broken](broken.md#broken) |\n| [Top](q1-sales.md#q1-sales) |\n| [Site](https://example.com/#broken) |"; !strings.Contains(files["q1-sales.md"], expected) {
+		t.Fatalf("expected sheet links to point into the bundle's files:\n%s", files["q1-sales.md"])
+	}
+	if files["sales-eu.md"] != CombineMarkdown(result.Sheets[:1]) {
+		t.Fatalf("unexpected sheet file:\n%s", files["sales-eu.md"])
+	}
+	readme := files["README.md"]
+	if !strings.HasPrefix(readme, "# Contents\n\n- [Sales \\[EU\\]](sales-eu.md)\n- [Broken](broken.md)\n- [Q1 Sales](q1-sales.md)\n\n## Sales [EU]") {
+		t.Fatalf("unexpected README:\n%s", readme)
+	}
+	if !strings.HasSuffix(readme, result.CombinedMarkdown) || !strings.Contains(readme, "[Broken](#broken)") {
+		t.Fatalf("README does not end with the combined document:\n%s", readme)
+	}
+
+	var manifest struct {
+		Meta   Meta `json:"meta"`
+		Sheets []struct {
+			Name     string   `json:"name"`
+			File     string   `json:"file"`
+			Warnings []string `json:"warnings"`
+			Error    string   `json:"error"`
+		} `json:"sheets"`
+		Skipped []SkippedSheet `json:"skipped"`
+	}
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.Meta.SkippedCount != 1 || len(manifest.Skipped) != 1 || manifest.Skipped[0].Reason != "hidden" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if manifest.Sheets[0].File != "sales-eu.md" || len(manifest.Sheets[0].Warnings) != 1 || manifest.Sheets[1].Error == "" {
+		t.Fatalf("unexpected manifest sheets: %+v", manifest.Sheets)
+	}
+}
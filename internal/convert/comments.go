@@ -0,0 +1,80 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Note is a cell comment listed in a "Notes" section under the sheet.
+type Note struct {
+	Cell   string
+	Author string
+	Text   string
+}
+
+// readNotes returns the comments of a sheet with their text flattened and
+// the "Author:" prefix Excel inserts removed.
+func readNotes(file *excelize.File, sheetName string) ([]Note, error) {
+	comments, err := file.GetComments(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]Note, 0, len(comments))
+	for _, comment := range comments {
+		text := comment.Text
+		if len(comment.Paragraph) > 0 {
+			text = joinRuns(comment.Paragraph)
+		}
+		text = strings.TrimSpace(text)
+		if comment.Author != "" {
+			text = strings.TrimSpace(strings.TrimPrefix(text, comment.Author+":"))
+		}
+		if text == "" {
+			continue
+		}
+		notes = append(notes, Note{Cell: comment.Cell, Author: comment.Author, Text: text})
+	}
+	return notes, nil
+}
+
+// applyComments attaches notes to the extracted rows according to mode.
+// Footnote markers are appended to the commented cell, extending the rows
+// when the cell lies outside the extracted data.
+func applyComments(data *sheetData, sheetName string, notes []Note, mode CommentMode) {
+	switch mode {
+	case CommentNotes:
+		data.notes = notes
+	case CommentFootnotes:
+		for _, note := range notes {
+			col, row, err := excelize.CellNameToCoordinates(note.Cell)
+			if err != nil {
+				continue
+			}
+			for len(data.rows) < row {
+				data.rows = append(data.rows, []string{})
+				data.rowNums = append(data.rowNums, len(data.rows))
+			}
+			cells := data.rows[row-1]
+			if len(cells) < col {
+				extended := make([]string, col)
+				copy(extended, cells)
+				cells = extended
+			}
+			label := footnoteLabel(sheetName, note.Cell, "")
+			data.setPlain(row, col, cells[col-1])
+			cells[col-1] += "[^" + label + "]"
+			data.rows[row-1] = cells
+			data.footnotes = append(data.footnotes, Footnote{Label: label, Text: noteText(note)})
+			data.rowCount = max(data.rowCount, row)
+			data.colCount = max(data.colCount, len(cells))
+		}
+	}
+}
+
+func noteText(note Note) string {
+	if note.Author == "" {
+		return note.Text
+	}
+	return note.Author + ": " + note.Text
+}
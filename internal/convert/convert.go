@@ -5,17 +5,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 var (
+	ErrInvalidFile       = errors.New("invalid xlsx file")
 	ErrTooManySheets     = errors.New("workbook has too many sheets")
 	ErrSheetTooLarge     = errors.New("sheet exceeds cell limit")
 	ErrConversionTimeout = errors.New("conversion timed out")
+	ErrInvalidOption     = errors.New("invalid option")
 )
 
 // Convert reads an XLSX byte slice and returns Markdown for each sheet.
@@ -32,124 +37,725 @@ func Convert(ctx context.Context, input []byte, opts Options) (Result, error) {
 		return result, err
 	}
 
-	file, err := excelize.OpenReader(bytes.NewReader(input))
+	if err := opts.Validate(); err != nil {
+		return result, err
+	}
+	renderer, _ := selectRenderer(opts)
+	if renderer != nil {
+		result.Format = strings.ToLower(strings.TrimSpace(opts.Format))
+	}
+	openOpts, _ := openOptions(opts)
+	rules, _ := parseRowRules(opts)
+
+	file, err := excelize.OpenReader(bytes.NewReader(input), openOpts)
 	if err != nil {
-		return result, fmt.Errorf("invalid xlsx file: %w", err)
+		return result, fmt.Errorf("%w: %w", ErrInvalidFile, err)
 	}
 	defer file.Close()
 
 	sheets := file.GetSheetList()
 	result.Meta.SheetCount = len(sheets)
+	var objects map[string][]namedObject
+	if opts.NamedObjects {
+		objects = readNamedObjects(file)
+	}
 
-	if opts.MaxSheets > 0 && len(sheets) > opts.MaxSheets {
+	selected := 0
+	for index, sheetName := range sheets {
+		if isSelected(opts, sheetName, index+1) {
+			selected++
+		}
+	}
+	if opts.MaxSheets > 0 && selected > opts.MaxSheets {
 		return result, ErrTooManySheets
 	}
 
-	for _, sheetName := range sheets {
+	jobs := []sheetJob{}
+	for index, sheetName := range sheets {
 		if err := checkCtx(ctx); err != nil {
 			return result, err
 		}
+		if !isSelected(opts, sheetName, index+1) {
+			skipSheet(&result, opts, index, sheetName, "not selected")
+			continue
+		}
 
 		hidden, hiddenErr := isHiddenSheet(file, sheetName)
 		if hiddenErr == nil && hidden && !opts.IncludeHiddenSheets {
-			result.Skipped = append(result.Skipped, SkippedSheet{
-				Name:   sheetName,
-				Reason: "hidden sheet",
-			})
+			skipSheet(&result, opts, index, sheetName, "hidden sheet")
 			continue
 		}
-
-		sheetResult := SheetResult{Name: sheetName}
-		rows, warnings, rowCount, colCount, err := extractSheet(ctx, file, sheetName, opts)
-		sheetResult.RowCount = rowCount
-		sheetResult.ColCount = colCount
-		if hiddenErr != nil {
-			warnings = append(warnings, "Sheet visibility could not be determined; processed as visible.")
-		}
-
-		if err != nil {
-			sheetResult.Error = err.Error()
-			result.Sheets = append(result.Sheets, sheetResult)
+		if opts.NamedObjects && len(objects[sheetName]) == 0 {
+			skipSheet(&result, opts, index, sheetName, "no tables or named ranges")
 			continue
 		}
+		jobs = append(jobs, sheetJob{index: index, name: sheetName, hiddenErr: hiddenErr})
+	}
 
-		markdown, _, _ := SheetToMarkdown(rows)
-		sheetResult.Markdown = markdown
-		sheetResult.Warnings = warnings
-		result.Sheets = append(result.Sheets, sheetResult)
+	converted := make([]SheetResult, len(jobs))
+	err = forEachSheet(ctx, len(jobs), opts.Concurrency, func(i int) {
+		job := jobs[i]
+		opts.report(ProgressEvent{Type: ProgressStarted, Sheet: job.name, Index: job.index + 1, Total: len(sheets)})
+		converted[i] = convertSheet(ctx, file, job, objects[job.name], opts, rules, renderer)
+		reportSheet(opts, converted[i], job.index, len(sheets))
+	})
+	if err != nil {
+		return result, err
+	}
+	// Options that do not fit a sheet fail only that sheet, unless they fit
+	// none of them.
+	invalid := 0
+	for _, sheet := range converted {
+		if errors.Is(sheet.Err, ErrInvalidOption) {
+			invalid++
+		}
 	}
+	if invalid > 0 && invalid == len(converted) {
+		return result, fmt.Errorf("sheet %q: %w", converted[0].Name, converted[0].Err)
+	}
+	result.Sheets = append(result.Sheets, converted...)
 
 	result.Meta.Processed = len(result.Sheets)
 	result.Meta.SkippedCount = len(result.Skipped)
 	result.CombinedMarkdown = CombineMarkdown(result.Sheets)
+	if renderer != nil {
+		result.CombinedOutput = renderer.Combine(result.Sheets)
+	}
 
 	return result, nil
 }
 
-func extractSheet(ctx context.Context, file *excelize.File, sheetName string, opts Options) ([][]string, []string, int, int, error) {
+// sheetJob is a sheet selected for conversion. index is its 0-based
+// position in the workbook.
+type sheetJob struct {
+	index     int
+	name      string
+	hiddenErr error
+}
+
+// convertSheet extracts and renders one sheet. Sheet errors are reported in
+// the result.
+func convertSheet(ctx context.Context, file *excelize.File, job sheetJob, objects []namedObject, opts Options, rules rowRules, renderer Renderer) SheetResult {
+	sheetResult := SheetResult{Name: job.name}
+	data, err := extractSheet(ctx, file, job.name, opts)
+	sheetResult.RowCount = data.rowCount
+	sheetResult.ColCount = data.colCount
+	sheetResult.FormulaCount = len(data.formulaCells)
+	sheetResult.FormulaCells = data.formulaCells
+	warnings := data.warnings
+	if job.hiddenErr != nil {
+		warnings = append(warnings, "Sheet visibility could not be determined; processed as visible.")
+	}
+
+	if err != nil {
+		sheetResult.Error = err.Error()
+		sheetResult.Err = err
+		return sheetResult
+	}
+
+	parts := tableParts(&data, job.name, objects, opts)
+	warnings = append(warnings, renderSheet(&sheetResult, &data, parts, job.index, opts, rules, renderer)...)
+	sheetResult.Warnings = warnings
+	return sheetResult
+}
+
+// skipSheet records a skipped sheet and reports it.
+func skipSheet(result *Result, opts Options, index int, sheetName, reason string) {
+	result.Skipped = append(result.Skipped, SkippedSheet{Name: sheetName, Reason: reason})
+	opts.report(ProgressEvent{Type: ProgressSkipped, Sheet: sheetName, Index: index + 1, Total: result.Meta.SheetCount, Reason: reason})
+}
+
+// reportSheet reports a converted sheet as finished or failed.
+func reportSheet(opts Options, sheet SheetResult, index, total int) {
+	event := ProgressEvent{Type: ProgressFinished, Sheet: sheet.Name, Index: index + 1, Total: total, Rows: sheet.RowCount, Warnings: sheet.Warnings}
+	if sheet.Error != "" {
+		event.Type, event.Rows, event.Warnings, event.Error = ProgressError, 0, nil, sheet.Error
+	}
+	opts.report(event)
+}
+
+// report passes event to the Progress callback, if any.
+func (opts Options) report(event ProgressEvent) {
+	if opts.Progress != nil {
+		opts.Progress(event)
+	}
+}
+
+// forEachSheet calls fn once for every index below n, on up to workers
+// goroutines at a time. It stops handing out indices when ctx is done and
+// then returns the context error after the running calls finish.
+func forEachSheet(ctx context.Context, n, workers int, fn func(i int)) error {
+	workers = max(1, min(workers, n))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+
+	var err error
+	for i := 0; i < n && err == nil; i++ {
+		if err = checkCtx(ctx); err != nil {
+			break
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			err = checkCtx(ctx)
+		}
+	}
+	close(next)
+	wg.Wait()
+	return err
+}
+
+// tablePart is one of several tables rendered from a sheet.
+type tablePart struct {
+	data *sheetData
+	name string
+	ref  string
+	// declared is set when the header comes from an Excel Table definition.
+	declared bool
+	// totals is the first sheet row of the table's totals row, 0 without
+	// one.
+	totals int
+}
+
+// tableParts splits data into the Excel Tables and defined names of the
+// sheet with NamedObjects, or into blocks with DetectTables. It returns nil
+// when the sheet is rendered as a whole.
+func tableParts(data *sheetData, sheetName string, objects []namedObject, opts Options) []tablePart {
+	parts := []tablePart{}
+	switch {
+	case opts.NamedObjects:
+		for _, object := range objects {
+			parts = append(parts, tablePart{
+				data:     objectData(data, object),
+				name:     object.name,
+				ref:      quoteSheetName(sheetName) + "!" + object.area.String(),
+				declared: object.table && len(object.columns) > 0,
+			})
+			if object.totalsRows > 0 {
+				parts[len(parts)-1].totals = object.area.lastRow - object.totalsRows + 1
+			}
+		}
+	case opts.DetectTables:
+		for _, island := range splitIslands(data) {
+			parts = append(parts, tablePart{data: island, ref: island.rangeRef(sheetName)})
+		}
+	}
+	return parts
+}
+
+// renderSheet fills in the Markdown and renderer output of sheetResult and
+// returns the warnings of rules. When the sheet was split into parts, each
+// is rendered as its own table. Rules that cannot be applied to a table
+// fail the sheet with their error.
+func renderSheet(sheetResult *SheetResult, data *sheetData, parts []tablePart, index int, opts Options, rules rowRules, renderer Renderer) []string {
+	fail := func(err error) {
+		sheetResult.Error = err.Error()
+		sheetResult.Err = err
+	}
+	if len(parts) == 0 {
+		warnings, err := rules.apply(data, opts, false, 0)
+		if err != nil {
+			fail(err)
+			return nil
+		}
+		meta := tableMeta(data, sheetResult.Name, index, opts)
+		meta.Footnotes = data.footnotes
+		meta.Notes = data.notes
+		sheetResult.Markdown, _ = MarkdownRenderer{}.Render(data.rows, meta)
+		if renderer != nil {
+			meta.Footnotes = nil
+			output, err := renderer.Render(data.text, meta)
+			if err != nil {
+				sheetResult.Error = err.Error()
+				sheetResult.Err = err
+			}
+			sheetResult.Output = output
+		}
+		return warnings
+	}
+
 	warnings := []string{}
+	for _, part := range parts {
+		table := TableResult{
+			Name:      part.name,
+			Range:     part.ref,
+			RowCount:  len(part.data.rows),
+			TotalsRow: part.totals > 0,
+		}
+		partWarnings, err := rules.apply(part.data, opts, part.declared, part.totals)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", table.title(), err))
+			return nil
+		}
+		for _, warning := range partWarnings {
+			warnings = append(warnings, table.title()+": "+warning)
+		}
+		table.ColCount = len(part.data.colNums)
+		meta := tableMeta(part.data, sheetResult.Name, index, opts)
+		meta.Table = table.title()
+		table.Markdown, _ = MarkdownRenderer{}.Render(part.data.rows, meta)
+		if renderer != nil {
+			output, err := renderer.Render(part.data.text, meta)
+			if err != nil && sheetResult.Err == nil {
+				sheetResult.Error = err.Error()
+				sheetResult.Err = err
+			}
+			table.Output = output
+		}
+		sheetResult.Tables = append(sheetResult.Tables, table)
+	}
+	sheetResult.Markdown = MarkdownRenderer{}.JoinTables(sheetResult.Tables) + markdownAppendix(data.notes, data.footnotes)
+	if renderer != nil {
+		sheetResult.Output = joinTables(renderer, sheetResult.Tables)
+	}
+	return warnings
+}
+
+// rowRules holds the parsed options that reshape a table before rendering.
+// plain is set when a renderer other than Markdown needs the rows without
+// markup.
+type rowRules struct {
+	header headerSpec
+	filter filterExpr
+	sort   []sortKey
+	plain  bool
+}
 
-	merges, err := file.GetMergeCells(sheetName)
-	if err == nil && len(merges) > 0 {
-		warnings = append(warnings, "Merged cells were flattened to their top-left value.")
+func parseRowRules(opts Options) (rowRules, error) {
+	header, err := parseHeaderMode(opts.HeaderMode)
+	if err != nil {
+		return rowRules{}, err
+	}
+	rules := rowRules{header: header}
+	if renderer, _ := selectRenderer(opts); renderer != nil {
+		rules.plain = true
+	}
+	if strings.TrimSpace(opts.Filter) != "" {
+		if rules.filter, err = parseFilter(opts.Filter); err != nil {
+			return rowRules{}, err
+		}
+	}
+	if rules.sort, err = parseSort(opts.Sort); err != nil {
+		return rowRules{}, err
+	}
+	return rules, nil
+}
+
+// apply drops hidden cells, selects the header (unless it was declared by an
+// Excel Table), filters and sorts the rows, then selects columns, so filters
+// may refer to excluded columns. Rows from sheet row totals on (a table's
+// totals row) stay at the end and are not filtered. Hidden cells are marked
+// last so the marker does not get in the way of matching header names; when
+// rules.plain is set, the rows without markup are copied to data.text just
+// before and marked the same way. A filter column that is missing, or column
+// selection that matches nothing, fails with ErrInvalidOption.
+func (rules rowRules) apply(data *sheetData, opts Options, declared bool, totals int) ([]string, error) {
+	warnings := []string{}
+	if opts.HiddenRowsCols == HiddenExclude {
+		warnings = append(warnings, dropHidden(data)...)
+	}
+	if !declared {
+		warnings = append(warnings, applyHeader(data, rules.header)...)
+	}
+	sorted, err := filterAndSort(data, rules.filter, rules.sort, totals)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, sorted...)
+	selected, err := selectColumns(data, opts.Columns, opts.ExcludeColumns)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, selected...)
+	if rules.plain {
+		data.text = data.plainRows()
+	}
+	for _, rows := range [][][]string{data.rows, data.text} {
+		if opts.HiddenRowsCols == HiddenMark {
+			markHidden(data, rows)
+		}
+		if opts.Outline == OutlineIndent || opts.Outline == OutlineArrow {
+			indentOutline(data, rows, opts.Outline)
+		}
+	}
+	return warnings, nil
+}
+
+// tableMeta describes one rendered table of a sheet.
+func tableMeta(data *sheetData, sheetName string, index int, opts Options) SheetMeta {
+	meta := SheetMeta{
+		Name:   sheetName,
+		Index:  index,
+		Align:  columnAlignments(data.rows, opts.AlignColumns, opts.ColumnAlignments, data.plainHeader(), data.colNum),
+		Merges: projectMerges(data),
+	}
+	if opts.Outline == OutlineList {
+		meta.Outline = outlineLevels(data)
+	}
+	return meta
+}
+
+// Validate reports option values that Convert would reject.
+func (opts Options) Validate() error {
+	renderer, err := selectRenderer(opts)
+	if err != nil {
+		return err
+	}
+	if _, err := openOptions(opts); err != nil {
+		return err
+	}
+	switch opts.FormulaMode {
+	case "", FormulaCached, FormulaText, FormulaBoth, FormulaRecalculate:
+	default:
+		return fmt.Errorf("%w: unknown formula mode %q", ErrInvalidOption, opts.FormulaMode)
+	}
+	switch opts.MergeStrategy {
+	case "", MergeTopLeft, MergeFill, MergeHTML:
+	default:
+		return fmt.Errorf("%w: unknown merge strategy %q", ErrInvalidOption, opts.MergeStrategy)
+	}
+	switch opts.Comments {
+	case "", CommentNone, CommentFootnotes, CommentNotes:
+	default:
+		return fmt.Errorf("%w: unknown comment mode %q", ErrInvalidOption, opts.Comments)
+	}
+	if renderer != nil && opts.Comments != "" && opts.Comments != CommentNone {
+		return fmt.Errorf("%w: comments can only be output as Markdown", ErrInvalidOption)
+	}
+	switch opts.Outline {
+	case "", OutlineNone, OutlineIndent, OutlineArrow, OutlineList:
+	default:
+		return fmt.Errorf("%w: unknown outline mode %q", ErrInvalidOption, opts.Outline)
+	}
+	switch opts.HiddenRowsCols {
+	case "", HiddenInclude, HiddenExclude, HiddenMark:
+	default:
+		return fmt.Errorf("%w: unknown hidden rows and columns mode %q", ErrInvalidOption, opts.HiddenRowsCols)
+	}
+	if opts.Concurrency < 0 {
+		return fmt.Errorf("%w: concurrency must not be negative", ErrInvalidOption)
+	}
+	if _, err := parseRowRules(opts); err != nil {
+		return err
+	}
+	for _, selector := range opts.Sheets {
+		if _, err := path.Match(selector, ""); err != nil {
+			return fmt.Errorf("%w: invalid sheet pattern %q", ErrInvalidOption, selector)
+		}
+	}
+	ranged := map[string]bool{}
+	for _, ref := range opts.Ranges {
+		if strings.TrimSpace(ref) == "" {
+			continue
+		}
+		sheet, _, err := parseRange(ref)
+		if err != nil {
+			return err
+		}
+		if ranged[strings.ToLower(sheet)] {
+			if sheet == "" {
+				return fmt.Errorf("%w: more than one range without a sheet name", ErrInvalidOption)
+			}
+			return fmt.Errorf("%w: more than one range for sheet %q", ErrInvalidOption, sheet)
+		}
+		ranged[strings.ToLower(sheet)] = true
+	}
+	return nil
+}
+
+// selectRenderer returns the renderer for non-Markdown output, or nil when
+// Markdown alone is requested.
+func selectRenderer(opts Options) (Renderer, error) {
+	if opts.Renderer != nil {
+		return opts.Renderer, nil
+	}
+	renderer, err := RendererFor(opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := renderer.(MarkdownRenderer); ok {
+		return nil, nil
+	}
+	return renderer, nil
+}
+
+// openOptions maps conversion options to workbook read options.
+func openOptions(opts Options) (excelize.Options, error) {
+	openOpts := excelize.Options{
+		ShortDatePattern: "yyyy-mm-dd",
+		LongTimePattern:  "hh:mm:ss",
+		CultureInfo:      excelize.CultureNameEnUS,
+	}
+	switch opts.Values {
+	case "", ValuesFormatted:
+	case ValuesRaw:
+		openOpts.RawCellValue = true
+	default:
+		return openOpts, fmt.Errorf("%w: unknown value mode %q", ErrInvalidOption, opts.Values)
+	}
+	if opts.UnzipSizeLimit < 0 || opts.UnzipXMLSizeLimit < 0 {
+		return openOpts, fmt.Errorf("%w: unzip size limits must not be negative", ErrInvalidOption)
+	}
+	openOpts.UnzipSizeLimit = opts.UnzipSizeLimit
+	openOpts.UnzipXMLSizeLimit = opts.UnzipXMLSizeLimit
+	openOpts.TmpDir = opts.TempDir
+	if opts.UnzipSizeLimit > 0 && opts.UnzipXMLSizeLimit > opts.UnzipSizeLimit {
+		openOpts.UnzipXMLSizeLimit = opts.UnzipSizeLimit
+	}
+	return openOpts, nil
+}
+
+// sheetData is the extracted content of one sheet before rendering.
+// rowNums holds the 1-based sheet row of each entry in rows (0 for
+// synthesized rows); colNums does the same for columns and is nil while
+// columns still map one-to-one onto the sheet.
+type sheetData struct {
+	rows       [][]string
+	rowNums    []int
+	colNums    []int
+	warnings   []string
+	footnotes  []Footnote
+	merges     []MergeRange
+	notes      []Note
+	frozenRows int
+	// hiddenRows and hiddenCols hold the sheet numbers of hidden rows and
+	// columns; they are only read when hidden cells are not included.
+	hiddenRows map[int]bool
+	hiddenCols map[int]bool
+	// outline maps sheet rows to their outline level when it is read.
+	outline map[int]int
+	// formulaCells lists the references of the formula cells read.
+	formulaCells []string
+	rowCount     int
+	colCount     int
+
+	// plain maps the 1-based sheet row and column of cells that rich text,
+	// link, formula or footnote markup changed to their text before it, so
+	// columns can be matched by header name. header is the plain header
+	// when several sheet rows were merged into rows[0].
+	plain  map[[2]int]string
+	header []string
+	// formulaText maps the sheet row and column of cells shown as formula
+	// text to that text without its code span.
+	formulaText map[[2]int]string
+	// text holds the rows without markup for renderers of formats other
+	// than Markdown; rowRules.apply sets it.
+	text [][]string
+}
+
+// colNum returns the 1-based sheet column of rendered column index col.
+func (data *sheetData) colNum(col int) int {
+	if data.colNums == nil {
+		return col + 1
+	}
+	if col < len(data.colNums) {
+		return data.colNums[col]
+	}
+	return 0
+}
+
+// keepPlain records the cells of sheet row rowNum whose text markup changed
+// from before to after.
+func (data *sheetData) keepPlain(before, after []string, rowNum int) {
+	for i := range min(len(before), len(after)) {
+		if before[i] != after[i] {
+			data.setPlain(rowNum, i+1, before[i])
+		}
+	}
+}
+
+// setPlain records the text of the cell at sheet row and col before markup,
+// unless earlier markup already did.
+func (data *sheetData) setPlain(row, col int, text string) {
+	if data.plain == nil {
+		data.plain = map[[2]int]string{}
+	}
+	if _, ok := data.plain[[2]int{row, col}]; !ok {
+		data.plain[[2]int{row, col}] = text
+	}
+}
+
+// plainRow returns a copy of rows[i] with the text the cells had before
+// markup was added.
+func (data *sheetData) plainRow(i int) []string {
+	row := append([]string{}, data.rows[i]...)
+	for col := range row {
+		if text, ok := data.plain[[2]int{data.rowNums[i], data.colNum(col)}]; ok {
+			row[col] = text
+		}
+	}
+	return row
+}
+
+// plainRows returns the rows as they read without markup, with formula text
+// in place of stored values where it was shown.
+func (data *sheetData) plainRows() [][]string {
+	rows := make([][]string, len(data.rows))
+	for i := range data.rows {
+		rows[i] = data.plainRow(i)
+		for col := range rows[i] {
+			if text, ok := data.formulaText[[2]int{data.rowNums[i], data.colNum(col)}]; ok {
+				rows[i][col] = text
+			}
+		}
+	}
+	if len(rows) > 0 && data.header != nil {
+		rows[0] = append([]string{}, data.header...)
+	}
+	return rows
+}
+
+// plainHeader returns the header row as it reads without markup, for
+// matching columns by name.
+func (data *sheetData) plainHeader() []string {
+	if data.header != nil {
+		return data.header
+	}
+	if len(data.rows) == 0 {
+		return nil
+	}
+	return data.plainRow(0)
+}
+
+func extractSheet(ctx context.Context, file *excelize.File, sheetName string, opts Options) (sheetData, error) {
+	data := sheetData{rows: [][]string{}, rowNums: []int{}, warnings: []string{}}
+	scan, scanErr := scanSheet(file, sheetName)
+	if scanErr != nil {
+		scan.formulas = nil
+		if panes, err := file.GetPanes(sheetName); err == nil && panes.Freeze {
+			scan.frozenRows = panes.YSplit
+		}
+	}
+	data.frozenRows = scan.frozenRows
+
+	var merges []MergeRange
+	var mergeErr error
+	if scanErr != nil || len(scan.merges) > 0 {
+		merges, mergeErr = readMerges(file, sheetName)
 	}
 
 	rows, err := file.Rows(sheetName)
 	if err != nil {
-		return nil, warnings, 0, 0, fmt.Errorf("unable to read sheet: %w", err)
+		return data, fmt.Errorf("unable to read sheet: %w", err)
 	}
 	defer rows.Close()
 
-	data := [][]string{}
-	maxCols := 0
-	rowIndex := 0
+	area, restricted, _ := sheetRange(opts, sheetName)
+	formulas := newFormulaHandler(file, sheetName, opts.FormulaMode, area, scan.formulas)
+	var richText *richTextHandler
+	if opts.RichText {
+		richText = newRichTextHandler(file, sheetName)
+	}
+	var links *hyperlinkHandler
+	if opts.Hyperlinks {
+		links = newHyperlinkHandler(file, sheetName)
+	}
+	decorated := richText != nil || links != nil || opts.FormulaMode == FormulaText || opts.FormulaMode == FormulaBoth
+	var before []string
 	cellCount := 0
-	formulaFound := false
 
 	for rows.Next() {
 		if err := checkCtx(ctx); err != nil {
-			return nil, warnings, rowIndex, maxCols, err
+			return data, err
 		}
-		rowIndex++
+		if restricted && area.lastRow > 0 && data.rowCount >= area.lastRow {
+			break
+		}
+		data.rowCount++
 		cols, err := rows.Columns()
 		if err != nil {
-			return nil, warnings, rowIndex, maxCols, fmt.Errorf("failed to read row: %w", err)
+			return data, fmt.Errorf("failed to read row: %w", err)
+		}
+		if restricted {
+			cols = area.clipRow(cols, data.rowCount)
+		}
+		if decorated {
+			before = append(before[:0], cols...)
+		}
+		if richText != nil {
+			richText.applyRow(cols, data.rowCount)
+		}
+		if links != nil {
+			links.applyRow(cols, data.rowCount)
+		}
+		formulas.applyRow(cols, data.rowCount)
+		if decorated {
+			data.keepPlain(before, cols, data.rowCount)
 		}
 		trimmed := trimTrailingEmpty(cols)
-		cellCount += len(trimmed)
+		if restricted {
+			cellCount += max(len(trimmed)-(area.firstCol-1), 0)
+		} else {
+			cellCount += len(trimmed)
+		}
 		if opts.MaxCellsPerSheet > 0 && cellCount > opts.MaxCellsPerSheet {
-			return nil, warnings, rowIndex, maxCols, ErrSheetTooLarge
-		}
-		if len(trimmed) > maxCols {
-			maxCols = len(trimmed)
-		}
-		data = append(data, trimmed)
-
-		if !formulaFound {
-			for i := range trimmed {
-				cellName, nameErr := excelize.ColumnNumberToName(i + 1)
-				if nameErr != nil {
-					continue
-				}
-				cellRef := fmt.Sprintf("%s%d", cellName, rowIndex)
-				formula, formulaErr := file.GetCellFormula(sheetName, cellRef)
-				if formulaErr == nil && formula != "" {
-					formulaFound = true
-					break
-				}
-			}
+			return data, ErrSheetTooLarge
+		}
+		if len(trimmed) > data.colCount {
+			data.colCount = len(trimmed)
 		}
+		data.rows = append(data.rows, trimmed)
+		data.rowNums = append(data.rowNums, data.rowCount)
 	}
 
 	if err := rows.Error(); err != nil {
-		return nil, warnings, rowIndex, maxCols, fmt.Errorf("failed to iterate rows: %w", err)
+		return data, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	if mergeErr == nil {
+		if warning := applyMerges(&data, merges, opts.MergeStrategy); warning != "" {
+			data.warnings = append(data.warnings, warning)
+		}
+	}
+	data.warnings = append(data.warnings, formulas.warnings()...)
+	data.footnotes = append(data.footnotes, formulas.footnotes...)
+	data.formulaCells = formulas.refs
+	data.formulaText = formulas.texts
+
+	if opts.Comments == CommentFootnotes || opts.Comments == CommentNotes {
+		notes, err := readNotes(file, sheetName)
+		if err != nil {
+			data.warnings = append(data.warnings, "Cell comments could not be read.")
+		}
+		if restricted {
+			notes = slices.DeleteFunc(notes, func(note Note) bool {
+				col, row, err := excelize.CellNameToCoordinates(note.Cell)
+				return err != nil || !area.contains(col, row)
+			})
+		}
+		applyComments(&data, sheetName, notes, opts.Comments)
+	}
+
+	if opts.HiddenRowsCols == HiddenExclude || opts.HiddenRowsCols == HiddenMark {
+		if err := readHidden(file, sheetName, &data); err != nil {
+			data.warnings = append(data.warnings, "Hidden rows and columns could not be read; they were included.")
+		}
+	}
+
+	if opts.Outline != "" && opts.Outline != OutlineNone {
+		if err := readOutline(file, sheetName, &data); err != nil {
+			data.warnings = append(data.warnings, "Row outline levels could not be read.")
+		}
 	}
 
-	if formulaFound {
-		warnings = append(warnings, "Formulas were detected; output uses stored values.")
+	// Excel Tables and defined names are cropped from the sheet layout
+	// later, so the range only blanks the cells outside it for them.
+	if restricted && !opts.NamedObjects {
+		part := data.crop(area)
+		data.rows, data.rowNums, data.colNums, data.frozenRows = part.rows, part.rowNums, part.colNums, part.frozenRows
+		data.rowCount, data.colCount = len(part.rows), len(part.colNums)
 	}
 
-	return data, warnings, rowIndex, maxCols, nil
+	return data, nil
 }
 
 func isHiddenSheet(file *excelize.File, sheetName string) (bool, error) {
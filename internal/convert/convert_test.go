@@ -1,7 +1,13 @@
 package convert
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/xuri/excelize/v2"
@@ -35,3 +41,595 @@ func TestConvertDetectsWarnings(t *testing.T) {
 		t.Fatalf("expected warnings for merges or formulas")
 	}
 }
+
+func TestConvertValueModes(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Share")
+	file.SetCellValue("Sheet1", "B1", "Date")
+	file.SetCellValue("Sheet1", "C1", "Amount")
+	file.SetCellValue("Sheet1", "A2", 0.153)
+	file.SetCellValue("Sheet1", "B2", 45123)
+	file.SetCellValue("Sheet1", "C2", 1234.5)
+	percent, _ := file.NewStyle(&excelize.Style{NumFmt: 10})
+	date, _ := file.NewStyle(&excelize.Style{NumFmt: 14})
+	currencyFormat := `"$"#,##0.00`
+	currency, _ := file.NewStyle(&excelize.Style{CustomNumFmt: &currencyFormat})
+	file.SetCellStyle("Sheet1", "A2", "A2", percent)
+	file.SetCellStyle("Sheet1", "B2", "B2", date)
+	file.SetCellStyle("Sheet1", "C2", "C2", currency)
+
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	formatted, err := Convert(context.Background(), buffer.Bytes(), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row := "| 15.30% | 2023-07-16 | $1,234.50 |"; !strings.Contains(formatted.Sheets[0].Markdown, row) {
+		t.Fatalf("expected formatted row %q in:\n%s", row, formatted.Sheets[0].Markdown)
+	}
+
+	raw, err := Convert(context.Background(), buffer.Bytes(), Options{Values: ValuesRaw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row := "| 0.153 | 45123 | 1234.5 |"; !strings.Contains(raw.Sheets[0].Markdown, row) {
+		t.Fatalf("expected raw row %q in:\n%s", row, raw.Sheets[0].Markdown)
+	}
+
+	if _, err := Convert(context.Background(), buffer.Bytes(), Options{Values: "pretty"}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption, got %v", err)
+	}
+}
+
+func TestConvertFormulaModes(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Value")
+	file.SetCellValue("Sheet1", "A2", 1)
+	file.SetCellValue("Sheet1", "A3", 2)
+	file.SetCellFormula("Sheet1", "A4", "SUM(A2:A3)")
+	file.SetCellFormula("Sheet1", "A5", "NOSUCHFN(A2)")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	cases := []struct {
+		mode     FormulaMode
+		contains []string
+		warning  string
+	}{
+		{FormulaCached, []string{"| 2 |"}, "Formulas were detected; output uses stored values."},
+		{FormulaText, []string{"| `=SUM(A2:A3)` |", "| `=NOSUCHFN(A2)` |"}, "Formulas are shown as formula text."},
+		{FormulaBoth, []string{"| [^Sheet1-A4-fx] |", "[^Sheet1-A4-fx]: `=SUM(A2:A3)`"}, "Formulas are shown as footnotes next to their stored values."},
+		{FormulaRecalculate, []string{"| 3 |"}, "Formula could not be recalculated: A5 (=NOSUCHFN(A2)): "},
+	}
+	for _, tc := range cases {
+		res, err := Convert(context.Background(), buffer.Bytes(), Options{FormulaMode: tc.mode})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.mode, err)
+		}
+		sheet := res.Sheets[0]
+		for _, want := range tc.contains {
+			if !strings.Contains(sheet.Markdown, want) {
+				t.Fatalf("%s: expected %q in:\n%s", tc.mode, want, sheet.Markdown)
+			}
+		}
+		found := false
+		for _, warning := range sheet.Warnings {
+			if strings.HasPrefix(warning, tc.warning) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s: expected warning %q, got %v", tc.mode, tc.warning, sheet.Warnings)
+		}
+	}
+}
+
+func TestConvertMergeStrategies(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Region")
+	file.SetCellValue("Sheet1", "B1", "Quarter")
+	file.SetCellValue("Sheet1", "A2", "North")
+	file.SetCellValue("Sheet1", "B2", "Q1")
+	file.SetCellValue("Sheet1", "B3", "Q2")
+	file.MergeCell("Sheet1", "A2", "A3")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	cases := []struct {
+		strategy MergeStrategy
+		contains string
+		warning  string
+	}{
+		{MergeTopLeft, "| North | Q1 |\n|  | Q2 |", "Merged cells were flattened to their top-left value: A2:A3."},
+		{MergeFill, "| North | Q1 |\n| North | Q2 |", "Merged cells were filled with their top-left value: A2:A3."},
+		{MergeHTML, "<tr><td rowspan=\"2\">North</td><td>Q1</td></tr>\n<tr><td>Q2</td></tr>", "Merged cells are rendered as an HTML table: A2:A3."},
+	}
+	for _, tc := range cases {
+		res, err := Convert(context.Background(), buffer.Bytes(), Options{MergeStrategy: tc.strategy})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.strategy, err)
+		}
+		sheet := res.Sheets[0]
+		if !strings.Contains(sheet.Markdown, tc.contains) {
+			t.Fatalf("%s: expected %q in:\n%s", tc.strategy, tc.contains, sheet.Markdown)
+		}
+		if len(sheet.Warnings) == 0 || sheet.Warnings[0] != tc.warning {
+			t.Fatalf("%s: unexpected warnings: %v", tc.strategy, sheet.Warnings)
+		}
+	}
+}
+
+func TestConvertHyperlinks(t *testing.T) {
+	file := excelize.NewFile()
+	file.NewSheet("Q1 Sales")
+	file.SetCellValue("Sheet1", "A1", "Link")
+	file.SetCellValue("Sheet1", "A2", "Docs [v2]")
+	file.SetCellHyperLink("Sheet1", "A2", "https://example.com/docs", "External")
+	file.SetCellValue("Sheet1", "A3", "Sales")
+	file.SetCellHyperLink("Sheet1", "A3", "'Q1 Sales'!A1", "Location")
+	file.SetCellValue("Sheet1", "A4", "Plain")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{Hyperlinks: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "| Link |\n| --- |\n| [Docs \\[v2\\]](https://example.com/docs) |\n| [Sales](#q1-sales) |\n| Plain |"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+}
+
+func TestConvertComments(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Task")
+	file.SetCellValue("Sheet1", "A2", "Ship")
+	file.AddComment("Sheet1", excelize.Comment{Cell: "A2", Author: "Ann", Paragraph: []excelize.RichTextRun{{Text: "Ann:"}, {Text: "Blocked on QA"}}})
+	file.AddComment("Sheet1", excelize.Comment{Cell: "B3", Author: "Raj", Paragraph: []excelize.RichTextRun{{Text: "Empty on purpose"}}})
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{Comments: CommentFootnotes})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "| Task |  |\n| --- | --- |\n| Ship[^Sheet1-A2] |  |\n|  | [^Sheet1-B3] |\n\n" +
+		"[^Sheet1-A2]: Ann: Blocked on QA\n[^Sheet1-B3]: Raj: Empty on purpose"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Comments: CommentNotes})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "| Task |\n| --- |\n| Ship |\n\n### Notes\n\n- **A2**: Ann: Blocked on QA\n- **B3**: Raj: Empty on purpose"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+
+	for _, mode := range []CommentMode{CommentFootnotes, CommentNotes} {
+		if _, err := Convert(context.Background(), buffer.Bytes(), Options{Comments: mode, Format: FormatCSV}); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("expected ErrInvalidOption for %s comments in CSV, got %v", mode, err)
+		}
+	}
+}
+
+func TestConvertDetectTables(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Report"})
+	file.SetSheetRow("Sheet1", "B3", &[]any{"Region", "Sales"})
+	file.SetSheetRow("Sheet1", "B4", &[]any{"North", 10})
+	file.SetSheetRow("Sheet1", "E3", &[]any{"Owner"})
+	file.SetSheetRow("Sheet1", "E4", &[]any{"Ann"})
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{DetectTables: true, Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sheet := res.Sheets[0]
+	ranges := []string{}
+	for _, table := range sheet.Tables {
+		ranges = append(ranges, table.Range)
+	}
+	if strings.Join(ranges, ",") != "Sheet1!A1:A1,Sheet1!B3:C4,Sheet1!E3:E4" {
+		t.Fatalf("unexpected tables: %v", ranges)
+	}
+	if !strings.Contains(sheet.Markdown, "### Sheet1!B3:C4\n\n| Region | Sales |\n| --- | --- |\n| North | 10 |") {
+		t.Fatalf("unexpected markdown:\n%s", sheet.Markdown)
+	}
+	if !strings.Contains(sheet.Output, `{"range":"Sheet1!E3:E4","rows":[{"Owner":"Ann"}]}`) {
+		t.Fatalf("unexpected output: %s", sheet.Output)
+	}
+}
+
+func TestConvertNamedObjects(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "scratch")
+	file.SetSheetRow("Sheet1", "B3", &[]any{"Region", "Sales"})
+	file.SetSheetRow("Sheet1", "B4", &[]any{"North", 10})
+	file.SetSheetRow("Sheet1", "B5", &[]any{"South", 20})
+	if err := file.AddTable("Sheet1", &excelize.Table{Range: "B3:C5", Name: "Sales"}); err != nil {
+		t.Fatalf("failed to add table: %v", err)
+	}
+	file.SetSheetRow("Sheet1", "E3", &[]any{"Rate", 0.2})
+	if err := file.SetDefinedName(&excelize.DefinedName{Name: "Rates", RefersTo: "Sheet1!$E$3:$F$3"}); err != nil {
+		t.Fatalf("failed to add defined name: %v", err)
+	}
+	file.NewSheet("Scratch")
+	file.SetCellValue("Scratch", "A1", "ignored")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{NamedObjects: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Sheets) != 1 || len(res.Skipped) != 1 || res.Skipped[0].Name != "Scratch" {
+		t.Fatalf("unexpected sheets: %+v skipped: %+v", res.Sheets, res.Skipped)
+	}
+	expected := "### Sales\n\n| Region | Sales |\n| --- | --- |\n| North | 10 |\n| South | 20 |\n\n" +
+		"### Rates\n\n| Rate | 0.2 |\n| --- | --- |"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+	if tables := res.Sheets[0].Tables; tables[0].Range != "Sheet1!B3:C5" || tables[1].Range != "Sheet1!E3:F3" {
+		t.Fatalf("unexpected tables: %+v", tables)
+	}
+}
+
+func TestConvertSelection(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetName("Sheet1", "Q1 2024")
+	file.NewSheet("Q2 2024")
+	file.NewSheet("Summary")
+	for _, sheet := range []string{"Q1 2024", "Q2 2024", "Summary"} {
+		file.SetCellValue(sheet, "A1", "notes")
+		file.SetSheetRow(sheet, "B3", &[]any{"Region", "Owner", "Sales"})
+		file.SetSheetRow(sheet, "B4", &[]any{"North", "Ann", 10})
+		file.SetCellValue(sheet, "Z100", "scratch")
+	}
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{
+		Sheets:           []string{"q? 2024"},
+		Ranges:           []string{"B3:D4"},
+		ExcludeColumns:   []string{"owner"},
+		MaxSheets:        2,
+		MaxCellsPerSheet: 6,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Sheets) != 2 || len(res.Skipped) != 1 || res.Skipped[0].Reason != "not selected" {
+		t.Fatalf("unexpected sheets: %+v skipped: %+v", res.Sheets, res.Skipped)
+	}
+	if res.Sheets[0].Markdown != "| Region | Sales |\n| --- | --- |\n| North | 10 |" {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Ranges: []string{"'Summary'!C:D"}, Columns: []string{"Sales", "Total"}, HeaderMode: HeaderRow(3)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Sheets) != 1 || res.Sheets[0].Name != "Summary" {
+		t.Fatalf("unexpected sheets: %+v", res.Sheets)
+	}
+	if res.Sheets[0].Markdown != "| Sales |\n| --- |\n| 10 |" {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+	if warnings := res.Sheets[0].Warnings; len(warnings) != 2 || warnings[1] != `Column "Total" was not found.` {
+		t.Fatalf("unexpected warnings: %v", res.Sheets[0].Warnings)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Ranges: []string{"'Q1 2024'!B3:C4", "Summary!C3:D4"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Sheets) != 2 || res.Sheets[0].Name != "Q1 2024" || res.Sheets[1].Name != "Summary" {
+		t.Fatalf("unexpected sheets: %+v", res.Sheets)
+	}
+	if res.Sheets[0].Markdown != "| Region | Owner |\n| --- | --- |\n| North | Ann |" || res.Sheets[1].Markdown != "| Owner | Sales |\n| --- | --- |\n| Ann | 10 |" {
+		t.Fatalf("unexpected markdown:\n%s\n%s", res.Sheets[0].Markdown, res.Sheets[1].Markdown)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Ranges: []string{"Summary!D3:D4", "B3:B4"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Sheets) != 3 || res.Sheets[0].Markdown != "| Region |\n| --- |\n| North |" || res.Sheets[2].Markdown != "| Sales |\n| --- |\n| 10 |" {
+		t.Fatalf("unexpected sheets: %+v", res.Sheets)
+	}
+
+	_, err = Convert(context.Background(), buffer.Bytes(), Options{Columns: []string{"Priority"}, HeaderMode: HeaderRow(3)})
+	if !errors.Is(err, ErrInvalidOption) || !strings.Contains(err.Error(), "Priority") {
+		t.Fatalf("expected invalid option error for unmatched columns, got %v", err)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Ranges: []string{"Summary!C3:D4", "B3:C4"}, Columns: []string{"Sales"}})
+	if err != nil {
+		t.Fatalf("expected only the sheets without the column to fail, got %v", err)
+	}
+	if !errors.Is(res.Sheets[0].Err, ErrInvalidOption) || !errors.Is(res.Sheets[1].Err, ErrInvalidOption) || res.Sheets[2].Err != nil {
+		t.Fatalf("unexpected sheet errors: %+v", res.Sheets)
+	}
+	if res.Sheets[2].Markdown != "| Sales |\n| --- |\n| 10 |" {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[2].Markdown)
+	}
+
+	for _, ranges := range [][]string{{"B3:"}, {"A1:B2", "C:D"}, {"Summary!A1", "summary!B2"}} {
+		if _, err := Convert(context.Background(), buffer.Bytes(), Options{Ranges: ranges}); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("expected invalid option error for %q, got %v", ranges, err)
+		}
+	}
+}
+
+func TestConvertMatchesPlainHeaders(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Status", "Priority", "Owner"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Open", 2, "Ann"})
+	file.SetSheetRow("Sheet1", "A3", &[]any{"Closed", 1, "Ben"})
+	file.SetSheetRow("Sheet1", "A4", &[]any{"Open", 1, "Cy"})
+	bold, _ := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	file.SetCellStyle("Sheet1", "A1", "A1", bold)
+	file.SetCellHyperLink("Sheet1", "B1", "https://example.com/priority", "External")
+	file.AddComment("Sheet1", excelize.Comment{Cell: "C1", Author: "Ann", Text: "Who owns it"})
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{
+		RichText:   true,
+		Hyperlinks: true,
+		Comments:   CommentFootnotes,
+		Filter:     `Status = "Open"`,
+		Sort:       "Priority",
+		Columns:    []string{"Status", "Priority", "Owner"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	markdown := res.Sheets[0].Markdown
+	if !strings.Contains(markdown, "| **Status** | [Priority](https://example.com/priority) | Owner[^Sheet1-C1] |") {
+		t.Fatalf("expected decorated header in:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "| Open | 1 | Cy |\n| Open | 2 | Ann |") || strings.Contains(markdown, "Closed") {
+		t.Fatalf("expected filtered and sorted rows in:\n%s", markdown)
+	}
+	if len(res.Sheets[0].Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", res.Sheets[0].Warnings)
+	}
+
+	_, err = Convert(context.Background(), buffer.Bytes(), Options{RichText: true, Filter: `State = "Open"`})
+	if !errors.Is(err, ErrInvalidOption) || !strings.Contains(err.Error(), `"State"`) {
+		t.Fatalf("expected an error for the unknown filter column, got %v", err)
+	}
+}
+
+func TestConvertFiltersPlainValues(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Status", "Owner"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Open", "Cy"})
+	file.SetSheetRow("Sheet1", "A3", &[]any{"Open", "Ann"})
+	file.SetSheetRow("Sheet1", "A4", &[]any{"Closed", "Ben"})
+	file.SetSheetRow("Sheet1", "A5", &[]any{"Open", "Bo"})
+	bold, _ := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	file.SetCellStyle("Sheet1", "A2", "B2", bold)
+	file.AddComment("Sheet1", excelize.Comment{Cell: "A3", Author: "Ann", Text: "Reopened"})
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{
+		RichText: true,
+		Comments: CommentFootnotes,
+		Filter:   `Status = "Open"`,
+		Sort:     "Owner",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	markdown := res.Sheets[0].Markdown
+	if !strings.Contains(markdown, "| Open[^Sheet1-A3] | Ann |\n| Open | Bo |\n| **Open** | **Cy** |") || strings.Contains(markdown, "Closed") {
+		t.Fatalf("expected rows filtered and sorted by their plain values in:\n%s", markdown)
+	}
+}
+
+func TestConvertHiddenRowsCols(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Item", "Helper", "Cost", "Calc"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Desk", "x", 100, "y"})
+	file.SetSheetRow("Sheet1", "A3", &[]any{"Lamp", "x", 20, "y"})
+	file.SetColVisible("Sheet1", "B", false)
+	file.SetColVisible("Sheet1", "D", false)
+	file.SetRowVisible("Sheet1", 3, false)
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{HiddenRowsCols: HiddenExclude})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Sheets[0].Markdown != "| Item | Cost |\n| --- | --- |\n| Desk | 100 |" {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+	expected := []string{"Hidden rows were dropped: 3.", "Hidden columns were dropped: B, D."}
+	if strings.Join(res.Sheets[0].Warnings, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected warnings: %v", res.Sheets[0].Warnings)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{HiddenRowsCols: HiddenMark})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(res.Sheets[0].Markdown, "| Item | Helper (hidden) | Cost | Calc (hidden) |") ||
+		!strings.Contains(res.Sheets[0].Markdown, "| (hidden) Lamp | x | 20 | y |") {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+}
+
+func TestConvertOutline(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Item", "Budget"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Travel", 1000})
+	file.SetSheetRow("Sheet1", "A3", &[]any{"Flights", 700})
+	file.SetSheetRow("Sheet1", "A4", &[]any{"Hotels", 300})
+	file.SetSheetRow("Sheet1", "A5", &[]any{"Long haul", 250})
+	file.SetRowOutlineLevel("Sheet1", 3, 1)
+	file.SetRowOutlineLevel("Sheet1", 4, 1)
+	file.SetRowOutlineLevel("Sheet1", 5, 2)
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{Outline: OutlineArrow})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(res.Sheets[0].Markdown, "| ↳ Hotels | 300 |\n| \u00a0\u00a0↳ Long haul | 250 |") {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Outline: OutlineList})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "- Travel (Budget: 1000)\n  - Flights (Budget: 700)\n  - Hotels (Budget: 300)\n    - Long haul (Budget: 250)"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+}
+
+func TestConvertConcurrency(t *testing.T) {
+	file := excelize.NewFile()
+	for i := range 12 {
+		sheet := fmt.Sprintf("Sheet%d", i+1)
+		if i > 0 {
+			file.NewSheet(sheet)
+		}
+		file.SetSheetRow(sheet, "A1", &[]any{"Item", "Cost", "Total"})
+		for row := 2; row < 40; row++ {
+			file.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &[]any{fmt.Sprintf("item %d", row), row * i})
+			file.SetCellFormula(sheet, fmt.Sprintf("C%d", row), fmt.Sprintf("B%d*2", row))
+		}
+		file.MergeCell(sheet, "A40", "C40")
+		file.SetCellHyperLink(sheet, "A2", "https://example.com", "External")
+	}
+	file.SetSheetVisible("Sheet5", false)
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	opts := Options{FormulaMode: FormulaBoth, Hyperlinks: true, RichText: true, Format: FormatJSON}
+	sequential, err := Convert(context.Background(), buffer.Bytes(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts.Concurrency = 4
+	parallel, err := Convert(context.Background(), buffer.Bytes(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(parallel.Sheets) != 11 || len(parallel.Skipped) != 1 {
+		t.Fatalf("unexpected sheets: %d skipped: %+v", len(parallel.Sheets), parallel.Skipped)
+	}
+	if parallel.CombinedMarkdown != sequential.CombinedMarkdown || parallel.CombinedOutput != sequential.CombinedOutput {
+		t.Fatalf("parallel output differs from sequential output")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Convert(ctx, buffer.Bytes(), opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context error, got %v", err)
+	}
+	if _, err := Convert(context.Background(), buffer.Bytes(), Options{Concurrency: -1}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected invalid option error, got %v", err)
+	}
+}
+
+func TestConvertProgress(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Name", "Amount"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Desk", 100})
+	file.NewSheet("Large")
+	file.SetSheetRow("Large", "A1", &[]any{"a", "b", "c", "d", "e"})
+	file.NewSheet("Hidden")
+	file.SetSheetVisible("Hidden", false)
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	var mu sync.Mutex
+	events := map[string][]ProgressType{}
+	progress := func(event ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if event.Total != 3 {
+			t.Errorf("unexpected total in %+v", event)
+		}
+		events[event.Sheet] = append(events[event.Sheet], event.Type)
+	}
+	if _, err := Convert(context.Background(), buffer.Bytes(), Options{Progress: progress, Concurrency: 2, MaxCellsPerSheet: 4}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]ProgressType{
+		"Sheet1": {ProgressStarted, ProgressFinished},
+		"Large":  {ProgressStarted, ProgressError},
+		"Hidden": {ProgressSkipped},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
+
+func TestConvertUnzipLimits(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Name", "Qty"})
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+	input := buffer.Bytes()
+
+	if _, err := Convert(context.Background(), input, Options{UnzipSizeLimit: 1 << 20, UnzipXMLSizeLimit: 16 << 20}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Convert(context.Background(), input, Options{UnzipSizeLimit: 64}); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("expected ErrInvalidFile past the unzip limit, got %v", err)
+	}
+	var out bytes.Buffer
+	if _, err := ConvertTo(context.Background(), bytes.NewReader(input), int64(len(input)), &out, Options{UnzipSizeLimit: 64}); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("expected ErrInvalidFile past the unzip limit when streaming, got %v", err)
+	}
+	if _, err := Convert(context.Background(), input, Options{UnzipSizeLimit: -1}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption, got %v", err)
+	}
+}
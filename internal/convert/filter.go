@@ -0,0 +1,415 @@
+package convert
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// filterExpr is a parsed row filter such as
+// `Status = "Open" and Priority <= 2`.
+type filterExpr interface {
+	// match evaluates the filter against a row; cell returns the value of
+	// a column by header name.
+	match(cell func(column string) string) bool
+	// columns lists the header names the filter refers to.
+	columns() []string
+}
+
+type logicalExpr struct {
+	and      bool
+	operands []filterExpr
+}
+
+func (e logicalExpr) match(cell func(string) string) bool {
+	for _, operand := range e.operands {
+		if operand.match(cell) != e.and {
+			return !e.and
+		}
+	}
+	return e.and
+}
+
+func (e logicalExpr) columns() []string {
+	columns := []string{}
+	for _, operand := range e.operands {
+		columns = append(columns, operand.columns()...)
+	}
+	return columns
+}
+
+type notExpr struct {
+	operand filterExpr
+}
+
+func (e notExpr) match(cell func(string) string) bool { return !e.operand.match(cell) }
+func (e notExpr) columns() []string                   { return e.operand.columns() }
+
+// comparison compares a column with a literal. Values compare as numbers
+// when both sides are numeric and as case-insensitive text otherwise.
+type comparison struct {
+	column string
+	op     string
+	value  string
+}
+
+func (c comparison) match(cell func(string) string) bool {
+	value := strings.TrimSpace(cell(c.column))
+	if c.op == "contains" {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(c.value))
+	}
+	result := compareValues(value, c.value)
+	switch c.op {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
+
+func (c comparison) columns() []string { return []string{c.column} }
+
+// compareValues orders two cell values: numerically when both are numbers
+// (thousands separators, currency symbols and % are ignored), otherwise as
+// case-insensitive text. ISO dates therefore order chronologically.
+func compareValues(a, b string) int {
+	x, okA := parseNumber(a)
+	y, okB := parseNumber(b)
+	if okA && okB {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func parseNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	for _, symbol := range currencySymbols {
+		value = strings.TrimSpace(strings.Replace(value, symbol, "", 1))
+	}
+	if !isNumber(value) {
+		return 0, false
+	}
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.ReplaceAll(strings.Trim(value, "()"), ",", "")
+	number, err := strconv.ParseFloat(value, 64)
+	if negative {
+		number = -number
+	}
+	return number, err == nil
+}
+
+// parseFilter parses a filter expression. Comparisons take the form
+// `Column op value` with op one of =, !=, <>, <, <=, >, >= or contains, and
+// combine with and, or, not and parentheses. Column names containing spaces
+// or operators are written in brackets, e.g. [Due Date]; text values are
+// quoted with " or '.
+func parseFilter(expr string) (filterExpr, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: filter: %w", ErrInvalidOption, err)
+	}
+	parser := &filterParser{tokens: tokens}
+	result, err := parser.or()
+	if err == nil && parser.pos < len(tokens) {
+		err = fmt.Errorf("unexpected %q", tokens[parser.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: filter: %w", ErrInvalidOption, err)
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenName
+	tokenString
+	tokenOp
+	tokenOpen
+	tokenClose
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			kind := tokenOpen
+			if r == ')' {
+				kind = tokenClose
+			}
+			tokens = append(tokens, filterToken{kind: kind, text: string(r)})
+			i++
+		case r == '"' || r == '\'' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			end := slices.Index(runes[i+1:], closing)
+			if end < 0 {
+				return nil, fmt.Errorf("missing closing %c", closing)
+			}
+			kind := tokenString
+			if r == '[' {
+				kind = tokenName
+			}
+			tokens = append(tokens, filterToken{kind: kind, text: string(runes[i+1 : i+1+end])})
+			i += end + 2
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && slices.Contains([]string{"==", "!=", "<>", "<=", ">="}, op+string(runes[i+1])) {
+				op += string(runes[i+1])
+			}
+			i += len(op)
+			switch op {
+			case "==":
+				op = "="
+			case "<>":
+				op = "!="
+			case "!":
+				return nil, fmt.Errorf("unexpected %q", op)
+			}
+			tokens = append(tokens, filterToken{kind: tokenOp, text: op})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()=!<>\"'[", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, text: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *filterParser) or() (filterExpr, error) {
+	return p.logical("or", false, p.and)
+}
+
+func (p *filterParser) and() (filterExpr, error) {
+	return p.logical("and", true, p.unary)
+}
+
+func (p *filterParser) logical(keyword string, and bool, operand func() (filterExpr, error)) (filterExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []filterExpr{first}
+	for p.peekKeyword(keyword) {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return logicalExpr{and: and, operands: operands}, nil
+}
+
+func (p *filterParser) unary() (filterExpr, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand: operand}, nil
+	}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOpen {
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenClose {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) comparison() (filterExpr, error) {
+	if p.pos+3 > len(p.tokens) {
+		return nil, fmt.Errorf("incomplete comparison")
+	}
+	column, op, value := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	if column.kind != tokenWord && column.kind != tokenName {
+		return nil, fmt.Errorf("expected a column name, got %q", column.text)
+	}
+	if op.kind == tokenWord && strings.EqualFold(op.text, "contains") {
+		op = filterToken{kind: tokenOp, text: "contains"}
+	}
+	if op.kind != tokenOp {
+		return nil, fmt.Errorf("expected an operator after %q", column.text)
+	}
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("expected a value after %q", op.text)
+	}
+	p.pos += 3
+	return comparison{column: column.text, op: op.text, value: value.text}, nil
+}
+
+// sortKey is one column of a sort expression.
+type sortKey struct {
+	column     string
+	descending bool
+}
+
+// parseSort parses a sort expression such as "-Updated, Priority": columns
+// by header name, comma-separated, prefixed with - for descending order.
+func parseSort(expr string) ([]sortKey, error) {
+	keys := []sortKey{}
+	for _, item := range strings.Split(expr, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key := sortKey{column: item}
+		switch item[0] {
+		case '-':
+			key = sortKey{column: strings.TrimSpace(item[1:]), descending: true}
+		case '+':
+			key = sortKey{column: strings.TrimSpace(item[1:])}
+		}
+		key.column = strings.TrimSuffix(strings.TrimPrefix(key.column, "["), "]")
+		if key.column == "" {
+			return nil, fmt.Errorf("%w: sort: missing column in %q", ErrInvalidOption, item)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// filterAndSort drops the data rows not matching filter and orders the rest
+// by keys. The sort is stable and puts empty values last. Columns are found
+// by header text, case-insensitively, and values are compared, both without
+// markup. Rows from sheet row totals on (unless it is 0) are kept at the end
+// and not filtered. A filter column that is missing fails with
+// ErrInvalidOption, so rows are never published unfiltered; a missing sort
+// column skips the sort with a warning.
+func filterAndSort(data *sheetData, filter filterExpr, keys []sortKey, totals int) ([]string, error) {
+	if len(data.rows) == 0 || (filter == nil && len(keys) == 0) {
+		return nil, nil
+	}
+	index := map[string]int{}
+	for col, name := range data.plainHeader() {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[name]; !ok && name != "" {
+			index[name] = col
+		}
+	}
+	missing := func(columns []string) string {
+		for _, column := range columns {
+			if _, ok := index[strings.ToLower(column)]; !ok {
+				return column
+			}
+		}
+		return ""
+	}
+	value := func(row []string, column string) string {
+		if col := index[strings.ToLower(column)]; col < len(row) {
+			return row[col]
+		}
+		return ""
+	}
+
+	warnings := []string{}
+	// Rows are compared by their text without markup; plain holds it.
+	type entry struct {
+		row    []string
+		plain  []string
+		rowNum int
+	}
+	entries, held := []entry{}, []entry{}
+	for i, row := range data.rows[1:] {
+		e := entry{row: row, plain: data.plainRow(i + 1), rowNum: data.rowNums[i+1]}
+		if totals > 0 && e.rowNum >= totals {
+			held = append(held, e)
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	if filter != nil {
+		if column := missing(filter.columns()); column != "" {
+			return nil, fmt.Errorf("%w: filter column %q was not found", ErrInvalidOption, column)
+		}
+		entries = slices.DeleteFunc(entries, func(e entry) bool {
+			return !filter.match(func(column string) string { return value(e.plain, column) })
+		})
+	}
+
+	if len(keys) > 0 {
+		columns := make([]string, len(keys))
+		for i, key := range keys {
+			columns[i] = key.column
+		}
+		if column := missing(columns); column != "" {
+			warnings = append(warnings, fmt.Sprintf("Sort column %q was not found; rows were not sorted.", column))
+		} else {
+			slices.SortStableFunc(entries, func(a, b entry) int {
+				for _, key := range keys {
+					x := strings.TrimSpace(value(a.plain, key.column))
+					y := strings.TrimSpace(value(b.plain, key.column))
+					if (x == "") != (y == "") {
+						if x == "" {
+							return 1
+						}
+						return -1
+					}
+					result := compareValues(x, y)
+					if key.descending {
+						result = -result
+					}
+					if result != 0 {
+						return result
+					}
+				}
+				return 0
+			})
+		}
+	}
+
+	data.rows = data.rows[:1]
+	data.rowNums = data.rowNums[:1]
+	for _, e := range append(entries, held...) {
+		data.rows = append(data.rows, e.row)
+		data.rowNums = append(data.rowNums, e.rowNum)
+	}
+	return warnings, nil
+}
@@ -0,0 +1,97 @@
+package convert
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	row := map[string]string{"Status": "open", "Priority": "2", "Due Date": "2024-03-01", "Cost": "$1,200.00"}
+	cell := func(column string) string { return row[column] }
+
+	cases := map[string]bool{
+		`Status = "Open" and Priority <= 2`:               true,
+		`Status = 'Closed' or Priority > 1`:               true,
+		`not (Status == Open)`:                            false,
+		`[Due Date] >= "2024-01-01" and Cost < 1000`:      false,
+		`Status <> "open" or Cost >= 1200`:                true,
+		`Status contains "PE" and not Priority = 3`:       true,
+		`(Priority = 1 or Priority = 2) and Cost > 999.5`: true,
+	}
+	for expr, expected := range cases {
+		filter, err := parseFilter(expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", expr, err)
+		}
+		if got := filter.match(cell); got != expected {
+			t.Fatalf("%q: expected %v, got %v", expr, expected, got)
+		}
+	}
+
+	for _, expr := range []string{`Status =`, `Status "Open"`, `(Status = 1`, `Status ! 1`, `[Status = 1`, `Status = 1 Priority`} {
+		if _, err := parseFilter(expr); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("expected ErrInvalidOption for %q, got %v", expr, err)
+		}
+	}
+}
+
+func TestFilterAndSort(t *testing.T) {
+	data := &sheetData{
+		rows: [][]string{
+			{"Task", "Status", "Priority", "Updated"},
+			{"a", "Open", "2", "2024-01-05"},
+			{"b", "Done", "1", "2024-02-01"},
+			{"c", "Open", "10", ""},
+			{"d", "open", "1", "2024-03-01"},
+		},
+		rowNums: []int{1, 2, 3, 4, 5},
+	}
+	filter, _ := parseFilter(`Status = "open"`)
+	keys, _ := parseSort("-Updated, Priority")
+	if warnings, err := filterAndSort(data, filter, keys, 0); err != nil || len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v, error: %v", warnings, err)
+	}
+	tasks := []string{}
+	for _, row := range data.rows[1:] {
+		tasks = append(tasks, row[0])
+	}
+	if !reflect.DeepEqual(tasks, []string{"d", "a", "c"}) || !reflect.DeepEqual(data.rowNums, []int{1, 5, 2, 4}) {
+		t.Fatalf("unexpected order: %v %v", tasks, data.rowNums)
+	}
+
+	keys, _ = parseSort("Owner")
+	if warnings, err := filterAndSort(data, nil, keys, 0); err != nil || len(warnings) != 1 {
+		t.Fatalf("expected a missing column warning, got %v, error: %v", warnings, err)
+	}
+
+	filter, _ = parseFilter(`Owner = "Ann"`)
+	if _, err := filterAndSort(data, filter, nil, 0); !errors.Is(err, ErrInvalidOption) || len(data.rows) != 4 {
+		t.Fatalf("expected a missing column error, got %v", err)
+	}
+}
+
+func TestFilterAndSortKeepsTotalsRow(t *testing.T) {
+	data := &sheetData{
+		rows: [][]string{
+			{"Item", "Qty"},
+			{"a", "1"},
+			{"b", "5"},
+			{"c", "2"},
+			{"Total", "8"},
+		},
+		rowNums: []int{3, 4, 5, 6, 7},
+	}
+	filter, _ := parseFilter(`Qty < 3`)
+	keys, _ := parseSort("-Qty")
+	if _, err := filterAndSort(data, filter, keys, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items := []string{}
+	for _, row := range data.rows[1:] {
+		items = append(items, row[0])
+	}
+	if !reflect.DeepEqual(items, []string{"c", "a", "Total"}) || !reflect.DeepEqual(data.rowNums, []int{3, 6, 4, 7}) {
+		t.Fatalf("expected the totals row last, got %v %v", items, data.rowNums)
+	}
+}
@@ -0,0 +1,135 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxCellWarnings caps per-cell warnings so a broken sheet does not flood
+// the result.
+const maxCellWarnings = 10
+
+// formulaHandler applies the formula mode to each extracted row.
+type formulaHandler struct {
+	file      *excelize.File
+	sheetName string
+	mode      FormulaMode
+	// area is the selected range; formula cells outside it are ignored.
+	// The zero value covers the whole sheet.
+	area cellRange
+	// cells are the formula cells found by scanSheet. When the sheet could
+	// not be scanned it is nil and every cell is probed instead.
+	cells formulaCells
+
+	refs         []string
+	recalculated int
+	failures     []string
+	footnotes    []Footnote
+	// texts maps the sheet row and column of cells shown as formula text
+	// to that text.
+	texts map[[2]int]string
+}
+
+// newFormulaHandler returns a handler for the formula cells found by
+// scanSheet, or one probing every cell when cells is nil.
+func newFormulaHandler(file *excelize.File, sheetName string, mode FormulaMode, area cellRange, cells formulaCells) *formulaHandler {
+	if mode == "" {
+		mode = FormulaCached
+	}
+	return &formulaHandler{file: file, sheetName: sheetName, mode: mode, area: area, cells: cells}
+}
+
+// applyRow records the formula cells of row (1-based rowNum) and rewrites
+// them in place unless the mode keeps stored values. Formula text comes from
+// the scan; excelize is only asked for the copies of shared formulas.
+func (h *formulaHandler) applyRow(cols []string, rowNum int) {
+	candidates := h.cells[rowNum]
+	if h.cells == nil {
+		candidates = make([]formulaCell, len(cols))
+		for i := range candidates {
+			candidates[i].col = i + 1
+		}
+	}
+
+	for _, cell := range candidates {
+		if !h.area.contains(cell.col, rowNum) {
+			continue
+		}
+		cellRef, err := excelize.CoordinatesToCellName(cell.col, rowNum)
+		if err != nil {
+			continue
+		}
+		rewrite := h.mode != FormulaCached && cell.col <= len(cols)
+		formula := cell.text
+		if h.cells == nil || (rewrite && formula == "") {
+			formula, err = h.file.GetCellFormula(h.sheetName, cellRef)
+			if err != nil || (h.cells == nil && formula == "") {
+				continue
+			}
+		}
+		h.refs = append(h.refs, cellRef)
+		if rewrite && formula != "" {
+			cols[cell.col-1] = h.apply(cellRef, cols[cell.col-1], formula)
+			if h.mode == FormulaText {
+				if h.texts == nil {
+					h.texts = map[[2]int]string{}
+				}
+				h.texts[[2]int{rowNum, cell.col}] = "=" + formula
+			}
+		}
+	}
+}
+
+func (h *formulaHandler) apply(cellRef, value, formula string) string {
+	switch h.mode {
+	case FormulaText:
+		return codeSpan("=" + formula)
+	case FormulaBoth:
+		label := footnoteLabel(h.sheetName, cellRef, "fx")
+		h.footnotes = append(h.footnotes, Footnote{Label: label, Text: codeSpan("=" + formula)})
+		return value + "[^" + label + "]"
+	case FormulaRecalculate:
+		if value != "" {
+			return value
+		}
+		calculated, err := h.file.CalcCellValue(h.sheetName, cellRef)
+		if err != nil {
+			h.failures = append(h.failures, fmt.Sprintf("%s (=%s): %v", cellRef, formula, err))
+			return value
+		}
+		h.recalculated++
+		return calculated
+	default:
+		return value
+	}
+}
+
+func (h *formulaHandler) warnings() []string {
+	if len(h.refs) == 0 {
+		return nil
+	}
+
+	warnings := []string{}
+	switch h.mode {
+	case FormulaCached:
+		warnings = append(warnings, "Formulas were detected; output uses stored values.")
+	case FormulaText:
+		warnings = append(warnings, "Formulas are shown as formula text.")
+	case FormulaBoth:
+		warnings = append(warnings, "Formulas are shown as footnotes next to their stored values.")
+	case FormulaRecalculate:
+		if h.recalculated > 0 {
+			warnings = append(warnings, fmt.Sprintf("%d formula(s) without stored values were recalculated.", h.recalculated))
+		}
+	}
+
+	for i, failure := range h.failures {
+		if i == maxCellWarnings {
+			warnings = append(warnings, fmt.Sprintf("%d more formula(s) could not be recalculated.", len(h.failures)-maxCellWarnings))
+			break
+		}
+		warnings = append(warnings, "Formula could not be recalculated: "+failure)
+	}
+	return warnings
+}
@@ -0,0 +1,105 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestScanSheet(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"A", "B", "Sum"})
+	for row := 2; row <= 4; row++ {
+		file.SetSheetRow("Sheet1", fmt.Sprintf("A%d", row), &[]any{row, row * 10})
+	}
+	shared, ref := excelize.STCellFormulaTypeShared, "C2:C4"
+	file.SetCellFormula("Sheet1", "C2", "A2+B2", excelize.FormulaOpts{Type: &shared, Ref: &ref})
+	file.SetCellFormula("Sheet1", "A5", "SUM(A2:A4)")
+	file.SetPanes("Sheet1", &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	file.MergeCell("Sheet1", "A6", "B6")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	input := buffer.Bytes()
+	opened, err := excelize.OpenReader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("failed to open xlsx: %v", err)
+	}
+	defer opened.Close()
+	scan, err := scanSheet(opened, "Sheet1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := formulaCells{2: {{col: 3, text: "A2+B2"}}, 3: {{col: 3}}, 4: {{col: 3}}, 5: {{col: 1, text: "SUM(A2:A4)"}}}
+	if !reflect.DeepEqual(scan.formulas, expected) || scan.frozenRows != 1 || len(scan.merges) != 1 {
+		t.Fatalf("unexpected scan: %+v", scan)
+	}
+
+	res, err := Convert(context.Background(), input, Options{FormulaMode: FormulaText, Ranges: []string{"B1:C5"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sheet := res.Sheets[0]
+	if sheet.FormulaCount != 3 || !reflect.DeepEqual(sheet.FormulaCells, []string{"C2", "C3", "C4"}) {
+		t.Fatalf("unexpected formula cells: %d %v", sheet.FormulaCount, sheet.FormulaCells)
+	}
+	if !strings.Contains(sheet.Markdown, "| 40 | `=A4+B4` |") {
+		t.Fatalf("expected the shared formula in:\n%s", sheet.Markdown)
+	}
+}
+
+// syntheticWorkbook builds a one-sheet workbook of rows x cols numbers with
+// a formula in every formulaEvery-th row of the last column (none when 0).
+func syntheticWorkbook(tb testing.TB, rows, cols, formulaEvery int) []byte {
+	tb.Helper()
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		tb.Fatalf("failed to build xlsx: %v", err)
+	}
+	for row := 1; row <= rows; row++ {
+		values := make([]any, cols)
+		for col := range values {
+			values[col] = row * col
+		}
+		if formulaEvery > 0 && row%formulaEvery == 0 {
+			values[cols-1] = excelize.Cell{Formula: fmt.Sprintf("SUM(A%d:B%d)", row, row), Value: row}
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := stream.SetRow(cell, values); err != nil {
+			tb.Fatalf("failed to build xlsx: %v", err)
+		}
+	}
+	if err := stream.Flush(); err != nil {
+		tb.Fatalf("failed to build xlsx: %v", err)
+	}
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		tb.Fatalf("failed to build xlsx: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func benchmarkConvert(b *testing.B, input []byte, opts Options) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Convert(context.Background(), input, opts); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkConvertFormulaFree(b *testing.B) {
+	benchmarkConvert(b, syntheticWorkbook(b, 5000, 20, 0), Options{})
+}
+
+func BenchmarkConvertSparseFormulas(b *testing.B) {
+	benchmarkConvert(b, syntheticWorkbook(b, 5000, 20, 100), Options{FormulaMode: FormulaBoth})
+}
@@ -0,0 +1,239 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// HeaderMode selects which rows become the table header. Besides the
+// constants it accepts "row:N" (header on sheet row N, rows above dropped)
+// and "rows:N-M" (rows N to M merged into one header, e.g. "Region / Q1").
+type HeaderMode string
+
+const (
+	// HeaderFirstRow uses the first row as the header (default).
+	HeaderFirstRow HeaderMode = "first-row"
+	// HeaderAuto skips leading blank and title rows and uses the first
+	// dense row. Frozen panes, when present, mark the end of the header and
+	// may yield a multi-row header.
+	HeaderAuto HeaderMode = "auto"
+	// HeaderNone keeps every row as data and adds A, B, C… column letters
+	// as the header.
+	HeaderNone HeaderMode = "none"
+)
+
+// HeaderRow returns the mode using sheet row n as the header.
+func HeaderRow(n int) HeaderMode {
+	return HeaderMode(fmt.Sprintf("row:%d", n))
+}
+
+// HeaderRows returns the mode merging sheet rows first to last into one
+// header.
+func HeaderRows(first, last int) HeaderMode {
+	return HeaderMode(fmt.Sprintf("rows:%d-%d", first, last))
+}
+
+// headerSpec is a parsed HeaderMode. first and last are 1-based sheet rows
+// for the row and rows forms.
+type headerSpec struct {
+	mode  HeaderMode
+	first int
+	last  int
+}
+
+func parseHeaderMode(mode HeaderMode) (headerSpec, error) {
+	value := HeaderMode(strings.ToLower(strings.TrimSpace(string(mode))))
+	switch value {
+	case "", HeaderFirstRow:
+		return headerSpec{mode: HeaderFirstRow}, nil
+	case HeaderAuto, HeaderNone:
+		return headerSpec{mode: value}, nil
+	}
+
+	kind, arg, _ := strings.Cut(string(value), ":")
+	switch kind {
+	case "row":
+		n, err := strconv.Atoi(arg)
+		if err == nil && n > 0 {
+			return headerSpec{mode: "row", first: n, last: n}, nil
+		}
+	case "rows":
+		from, to, _ := strings.Cut(arg, "-")
+		first, errFirst := strconv.Atoi(from)
+		last, errLast := strconv.Atoi(to)
+		if errFirst == nil && errLast == nil && first > 0 && last >= first {
+			return headerSpec{mode: "rows", first: first, last: last}, nil
+		}
+	}
+	return headerSpec{}, fmt.Errorf("%w: unknown header mode %q", ErrInvalidOption, mode)
+}
+
+// applyHeader reshapes data.rows so that the first row is the header and
+// returns warnings describing rows that were skipped.
+func applyHeader(data *sheetData, spec headerSpec) []string {
+	switch spec.mode {
+	case HeaderNone:
+		insertColumnLetters(data)
+		return nil
+	case HeaderAuto:
+		first, last, ok := detectHeader(data.rows, data.frozenRows)
+		if !ok || (first == 0 && last == 0) {
+			return nil
+		}
+		warning := fmt.Sprintf("Header detected at %s.", describeRows(data, first, last))
+		if first > 0 {
+			warning = fmt.Sprintf("Header detected at %s; %d row(s) above it were skipped.", describeRows(data, first, last), first)
+		}
+		setHeader(data, first, last)
+		return []string{warning}
+	case "row", "rows":
+		first := indexOfRow(data.rowNums, spec.first)
+		last := indexOfRow(data.rowNums, spec.last)
+		if first < 0 || last < 0 {
+			return []string{fmt.Sprintf("Header row %d is outside the sheet data; the first row was used instead.", spec.first)}
+		}
+		setHeader(data, first, last)
+		if first > 0 {
+			return []string{fmt.Sprintf("%d row(s) above the header were skipped.", first)}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// setHeader drops the rows before first and merges rows first..last into a
+// single header row.
+func setHeader(data *sheetData, first, last int) {
+	if first < last && len(data.plain) > 0 {
+		plain := [][]string{}
+		for i := first; i <= last; i++ {
+			plain = append(plain, data.plainRow(i))
+		}
+		data.header = mergeHeaderRows(plain)
+	}
+	header := mergeHeaderRows(data.rows[first : last+1])
+	rows := append([][]string{header}, data.rows[last+1:]...)
+	rowNums := append([]int{data.rowNums[first]}, data.rowNums[last+1:]...)
+	data.rows = rows
+	data.rowNums = rowNums
+}
+
+// detectHeader finds the header rows. With frozen panes the last frozen row
+// is the header when it is dense, extended upward over adjacent group label
+// rows; otherwise the first dense row is used.
+func detectHeader(rows [][]string, frozenRows int) (int, int, bool) {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(trimTrailingEmpty(row)))
+	}
+	if width == 0 {
+		return 0, 0, false
+	}
+	threshold := max((width+1)/2, min(2, width))
+
+	if frozenRows > 0 && frozenRows <= len(rows) && countNonEmpty(rows[frozenRows-1]) >= threshold {
+		last := frozenRows - 1
+		first := last
+		for first > 0 && countNonEmpty(rows[first-1]) > 0 && !isTitleRow(rows[first-1]) {
+			first--
+		}
+		return first, last, true
+	}
+
+	for i, row := range rows {
+		if countNonEmpty(row) >= threshold {
+			return i, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// isTitleRow reports rows holding a single value in the first column, the
+// usual shape of a sheet title above a table.
+func isTitleRow(row []string) bool {
+	return countNonEmpty(row) == 1 && len(row) > 0 && strings.TrimSpace(row[0]) != ""
+}
+
+// mergeHeaderRows joins multi-row headers per column with " / ". Blank cells
+// in upper rows inherit the label to their left so grouped headers such as
+// "Q1" spanning two columns apply to both.
+func mergeHeaderRows(rows [][]string) []string {
+	if len(rows) == 1 {
+		return rows[0]
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	header := make([]string, width)
+	previous := make([]string, width)
+	for r, row := range rows {
+		carry := ""
+		for col := 0; col < width; col++ {
+			value := ""
+			if col < len(row) {
+				value = strings.TrimSpace(row[col])
+			}
+			if r < len(rows)-1 {
+				if value == "" {
+					value = carry
+				} else {
+					carry = value
+				}
+			}
+			if value == "" || value == previous[col] {
+				continue
+			}
+			if header[col] != "" {
+				header[col] += " / "
+			}
+			header[col] += value
+			previous[col] = value
+		}
+	}
+	return header
+}
+
+func insertColumnLetters(data *sheetData) {
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+	header := make([]string, width)
+	for col := range header {
+		header[col], _ = excelize.ColumnNumberToName(data.colNum(col))
+	}
+	data.rows = append([][]string{header}, data.rows...)
+	data.rowNums = append([]int{0}, data.rowNums...)
+}
+
+func describeRows(data *sheetData, first, last int) string {
+	if first == last {
+		return fmt.Sprintf("row %d", data.rowNums[first])
+	}
+	return fmt.Sprintf("rows %d-%d", data.rowNums[first], data.rowNums[last])
+}
+
+func indexOfRow(rowNums []int, rowNum int) int {
+	for i, n := range rowNums {
+		if n == rowNum {
+			return i
+		}
+	}
+	return -1
+}
+
+func countNonEmpty(row []string) int {
+	count := 0
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,82 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseHeaderMode(t *testing.T) {
+	spec, err := parseHeaderMode("rows:2-3")
+	if err != nil || spec.first != 2 || spec.last != 3 {
+		t.Fatalf("unexpected spec %+v (%v)", spec, err)
+	}
+	for _, mode := range []HeaderMode{"row:0", "rows:3-2", "second"} {
+		if _, err := parseHeaderMode(mode); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("expected ErrInvalidOption for %q, got %v", mode, err)
+		}
+	}
+}
+
+func TestMergeHeaderRows(t *testing.T) {
+	header := mergeHeaderRows([][]string{
+		{"Region", "Q1", "", "Q2"},
+		{"Region", "Sales", "Units", "Sales"},
+	})
+	expected := []string{"Region", "Q1 / Sales", "Q1 / Units", "Q2 / Sales"}
+	if !reflect.DeepEqual(header, expected) {
+		t.Fatalf("unexpected header: %q", header)
+	}
+}
+
+func TestConvertHeaderModes(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Quarterly report")
+	file.SetCellValue("Sheet1", "B3", "Q1")
+	file.SetCellValue("Sheet1", "A4", "Region")
+	file.SetCellValue("Sheet1", "B4", "Sales")
+	file.SetCellValue("Sheet1", "C4", "Units")
+	file.SetCellValue("Sheet1", "A5", "North")
+	file.SetCellValue("Sheet1", "B5", 10)
+	file.SetCellValue("Sheet1", "C5", 2)
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	cases := []struct {
+		mode     HeaderMode
+		expected string
+	}{
+		{HeaderAuto, "| Region | Sales | Units |\n| --- | --- | --- |\n| North | 10 | 2 |"},
+		{HeaderRows(3, 4), "| Region | Q1 / Sales | Q1 / Units |\n| --- | --- | --- |\n| North | 10 | 2 |"},
+		{HeaderRow(4), "| Region | Sales | Units |\n| --- | --- | --- |\n| North | 10 | 2 |"},
+		{HeaderNone, "| A | B | C |\n| --- | --- | --- |\n| Quarterly report |  |  |\n|  |  |  |\n|  | Q1 |  |\n| Region | Sales | Units |\n| North | 10 | 2 |"},
+	}
+	for _, tc := range cases {
+		res, err := Convert(context.Background(), buffer.Bytes(), Options{HeaderMode: tc.mode})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.mode, err)
+		}
+		if res.Sheets[0].Markdown != tc.expected {
+			t.Fatalf("%s: unexpected markdown:\n%s", tc.mode, res.Sheets[0].Markdown)
+		}
+	}
+
+	file.SetPanes("Sheet1", &excelize.Panes{Freeze: true, YSplit: 4, TopLeftCell: "A5", ActivePane: "bottomLeft"})
+	buffer, _ = file.WriteToBuffer()
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{HeaderMode: HeaderAuto})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "| Region | Q1 / Sales | Q1 / Units |\n| --- | --- | --- |\n| North | 10 | 2 |"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("frozen panes: unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+	if len(res.Sheets[0].Warnings) != 1 || res.Sheets[0].Warnings[0] != "Header detected at rows 3-4; 2 row(s) above it were skipped." {
+		t.Fatalf("unexpected warnings: %v", res.Sheets[0].Warnings)
+	}
+}
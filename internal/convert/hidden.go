@@ -0,0 +1,146 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// HiddenMode selects how hidden rows and columns of visible sheets are
+// output.
+type HiddenMode string
+
+const (
+	// HiddenInclude outputs hidden rows and columns like any other
+	// (default).
+	HiddenInclude HiddenMode = "include"
+	// HiddenExclude drops hidden rows and columns and lists them in a
+	// warning.
+	HiddenExclude HiddenMode = "exclude"
+	// HiddenMark keeps hidden rows and columns and marks them with
+	// "(hidden)": after the header of a column, before the first cell of a
+	// row.
+	HiddenMark HiddenMode = "mark"
+)
+
+const hiddenMarker = "(hidden)"
+
+// readHidden records the hidden rows and columns among the extracted ones.
+func readHidden(file *excelize.File, sheetName string, data *sheetData) error {
+	data.hiddenRows = map[int]bool{}
+	data.hiddenCols = map[int]bool{}
+	for row := 1; row <= data.rowCount; row++ {
+		visible, err := file.GetRowVisible(sheetName, row)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			data.hiddenRows[row] = true
+		}
+	}
+	for col := 1; col <= data.colCount; col++ {
+		name, _ := excelize.ColumnNumberToName(col)
+		visible, err := file.GetColVisible(sheetName, name)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			data.hiddenCols[col] = true
+		}
+	}
+	return nil
+}
+
+// dropHidden removes hidden rows and columns and returns a warning listing
+// them.
+func dropHidden(data *sheetData) []string {
+	if len(data.hiddenRows) == 0 && len(data.hiddenCols) == 0 {
+		return nil
+	}
+	droppedRows := []int{}
+	rows := data.rows[:0]
+	rowNums := data.rowNums[:0]
+	for i, row := range data.rows {
+		if n := data.rowNums[i]; data.hiddenRows[n] {
+			droppedRows = append(droppedRows, n)
+			continue
+		}
+		rows = append(rows, row)
+		rowNums = append(rowNums, data.rowNums[i])
+	}
+	data.rows, data.rowNums = rows, rowNums
+
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+	droppedCols := []int{}
+	keep := []int{}
+	for col := 0; col < width; col++ {
+		if n := data.colNum(col); data.hiddenCols[n] {
+			droppedCols = append(droppedCols, n)
+			continue
+		}
+		keep = append(keep, col)
+	}
+	if len(droppedCols) > 0 {
+		keepColumns(data, keep)
+	}
+
+	warnings := []string{}
+	if len(droppedRows) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Hidden rows were dropped: %s.", describeRuns(droppedRows, strconv.Itoa)))
+	}
+	if len(droppedCols) > 0 {
+		letter := func(n int) string {
+			name, _ := excelize.ColumnNumberToName(n)
+			return name
+		}
+		warnings = append(warnings, fmt.Sprintf("Hidden columns were dropped: %s.", describeRuns(droppedCols, letter)))
+	}
+	return warnings
+}
+
+// markHidden flags hidden columns in the header and hidden data rows in
+// their first cell. rows holds the cells of data as they are rendered.
+func markHidden(data *sheetData, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+	for col := range rows[0] {
+		if data.hiddenCols[data.colNum(col)] {
+			rows[0][col] = strings.TrimSpace(rows[0][col] + " " + hiddenMarker)
+		}
+	}
+	for i := 1; i < len(rows); i++ {
+		if !data.hiddenRows[data.rowNums[i]] {
+			continue
+		}
+		if len(rows[i]) == 0 {
+			rows[i] = []string{hiddenMarker}
+			continue
+		}
+		rows[i][0] = strings.TrimSpace(hiddenMarker + " " + rows[i][0])
+	}
+}
+
+// describeRuns lists ascending numbers with consecutive runs collapsed the
+// way Excel writes them, e.g. "4, 7:9" or "C, E:G".
+func describeRuns(numbers []int, format func(int) string) string {
+	parts := []string{}
+	for i := 0; i < len(numbers); {
+		j := i
+		for j+1 < len(numbers) && numbers[j+1] == numbers[j]+1 {
+			j++
+		}
+		part := format(numbers[i])
+		if j > i {
+			part += ":" + format(numbers[j])
+		}
+		parts = append(parts, part)
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
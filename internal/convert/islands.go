@@ -0,0 +1,197 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// block is a rectangle of data.rows positions, inclusive.
+type block struct {
+	top, bottom, left, right int
+}
+
+// splitIslands splits a sheet into blocks separated by fully blank rows or
+// columns, recursively alternating between the two (XY-cut). Blocks are
+// returned top to bottom, then left to right, trimmed to their contents.
+func splitIslands(data *sheetData) []*sheetData {
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+	if len(data.rows) == 0 || width == 0 {
+		return nil
+	}
+
+	blank := func(row, col int) bool {
+		cells := data.rows[row]
+		return col >= len(cells) || strings.TrimSpace(cells[col]) == ""
+	}
+
+	var split func(b block) []block
+	split = func(b block) []block {
+		b, ok := shrinkBlock(b, blank)
+		if !ok {
+			return nil
+		}
+		if parts := segments(b.top, b.bottom, func(row int) bool {
+			for col := b.left; col <= b.right; col++ {
+				if !blank(row, col) {
+					return false
+				}
+			}
+			return true
+		}); len(parts) > 1 {
+			blocks := []block{}
+			for _, part := range parts {
+				blocks = append(blocks, split(block{part[0], part[1], b.left, b.right})...)
+			}
+			return blocks
+		}
+		if parts := segments(b.left, b.right, func(col int) bool {
+			for row := b.top; row <= b.bottom; row++ {
+				if !blank(row, col) {
+					return false
+				}
+			}
+			return true
+		}); len(parts) > 1 {
+			blocks := []block{}
+			for _, part := range parts {
+				blocks = append(blocks, split(block{b.top, b.bottom, part[0], part[1]})...)
+			}
+			return blocks
+		}
+		return []block{b}
+	}
+
+	islands := []*sheetData{}
+	for _, b := range split(block{0, len(data.rows) - 1, 0, width - 1}) {
+		islands = append(islands, data.sub(b))
+	}
+	return islands
+}
+
+// shrinkBlock trims blank rows and columns from the edges of b.
+func shrinkBlock(b block, blank func(row, col int) bool) (block, bool) {
+	rowBlank := func(row int) bool {
+		for col := b.left; col <= b.right; col++ {
+			if !blank(row, col) {
+				return false
+			}
+		}
+		return true
+	}
+	for b.top <= b.bottom && rowBlank(b.top) {
+		b.top++
+	}
+	for b.bottom >= b.top && rowBlank(b.bottom) {
+		b.bottom--
+	}
+	if b.top > b.bottom {
+		return b, false
+	}
+	colBlank := func(col int) bool {
+		for row := b.top; row <= b.bottom; row++ {
+			if !blank(row, col) {
+				return false
+			}
+		}
+		return true
+	}
+	for b.left <= b.right && colBlank(b.left) {
+		b.left++
+	}
+	for b.right >= b.left && colBlank(b.right) {
+		b.right--
+	}
+	return b, b.left <= b.right
+}
+
+// segments returns the [start, end] runs between positions for which
+// separator reports true.
+func segments(from, to int, separator func(int) bool) [][2]int {
+	parts := [][2]int{}
+	start := -1
+	for i := from; i <= to; i++ {
+		if separator(i) {
+			if start >= 0 {
+				parts = append(parts, [2]int{start, i - 1})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		parts = append(parts, [2]int{start, to})
+	}
+	return parts
+}
+
+// sub copies the cells of b into a new sheetData keeping sheet positions,
+// merges and the part of the frozen pane that falls inside the block.
+func (data *sheetData) sub(b block) *sheetData {
+	island := &sheetData{
+		rows:    make([][]string, 0, b.bottom-b.top+1),
+		rowNums: make([]int, 0, b.bottom-b.top+1),
+		colNums: make([]int, 0, b.right-b.left+1),
+		merges:  data.merges,
+		plain:   data.plain,
+
+		formulaText: data.formulaText,
+
+		hiddenRows: data.hiddenRows,
+		hiddenCols: data.hiddenCols,
+		outline:    data.outline,
+	}
+	for col := b.left; col <= b.right; col++ {
+		island.colNums = append(island.colNums, data.colNum(col))
+	}
+	for row := b.top; row <= b.bottom; row++ {
+		cells := make([]string, b.right-b.left+1)
+		if b.left < len(data.rows[row]) {
+			copy(cells, data.rows[row][b.left:min(b.right+1, len(data.rows[row]))])
+		}
+		island.rows = append(island.rows, trimTrailingEmpty(cells))
+		island.rowNums = append(island.rowNums, data.rowNums[row])
+		if data.rowNums[row] > 0 && data.rowNums[row] <= data.frozenRows {
+			island.frozenRows++
+		}
+	}
+	return island
+}
+
+// rangeRef returns the A1 range covered by data, qualified with the sheet
+// name, e.g. "Sheet1!B3:F20" or "'Q1 Sales'!A1:C4".
+func (data *sheetData) rangeRef(sheetName string) string {
+	firstRow, lastRow := 0, 0
+	for _, n := range data.rowNums {
+		if n == 0 {
+			continue
+		}
+		if firstRow == 0 || n < firstRow {
+			firstRow = n
+		}
+		lastRow = max(lastRow, n)
+	}
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+	firstCol, lastCol := data.colNum(0), data.colNum(max(width-1, 0))
+	start, _ := excelize.CoordinatesToCellName(max(firstCol, 1), max(firstRow, 1))
+	end, _ := excelize.CoordinatesToCellName(max(lastCol, 1), max(lastRow, 1))
+	return quoteSheetName(sheetName) + "!" + start + ":" + end
+}
+
+func quoteSheetName(name string) string {
+	if strings.ContainsFunc(name, func(r rune) bool {
+		return !(r == '_' || r == '.' || (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z'))
+	}) {
+		return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// hyperlinkHandler rewrites linked cells as Markdown links.
+type hyperlinkHandler struct {
+	file      *excelize.File
+	sheetName string
+	sheets    map[string]string
+}
+
+func newHyperlinkHandler(file *excelize.File, sheetName string) *hyperlinkHandler {
+	sheets := map[string]string{}
+	for _, name := range file.GetSheetList() {
+		sheets[strings.ToLower(name)] = name
+	}
+	return &hyperlinkHandler{file: file, sheetName: sheetName, sheets: sheets}
+}
+
+// applyRow rewrites the non-empty linked cells of row (1-based rowNum) in
+// place.
+func (h *hyperlinkHandler) applyRow(cols []string, rowNum int) {
+	for i, value := range cols {
+		if value == "" {
+			continue
+		}
+		cellRef, err := excelize.CoordinatesToCellName(i+1, rowNum)
+		if err != nil {
+			continue
+		}
+		ok, target, err := h.file.GetCellHyperLink(h.sheetName, cellRef)
+		if err != nil || !ok || target == "" {
+			continue
+		}
+		cols[i] = markdownLink(value, h.resolve(target))
+	}
+}
+
+// resolve turns internal locations such as "'Q1 Sales'!A1" into an anchor
+// for the "## Q1 Sales" heading of CombineMarkdown. Everything else is
+// returned unchanged.
+func (h *hyperlinkHandler) resolve(target string) string {
+	if strings.Contains(target, "://") || strings.HasPrefix(strings.ToLower(target), "mailto:") {
+		return target
+	}
+	location := strings.TrimPrefix(target, "#")
+	sheet := location
+	if index := strings.LastIndex(location, "!"); index >= 0 {
+		sheet = location[:index]
+	}
+	if strings.HasPrefix(sheet, "'") && strings.HasSuffix(sheet, "'") && len(sheet) > 1 {
+		sheet = strings.ReplaceAll(sheet[1:len(sheet)-1], "''", "'")
+	}
+	if name, ok := h.sheets[strings.ToLower(sheet)]; ok {
+		return "#" + headingAnchor(name)
+	}
+	return target
+}
+
+func markdownLink(text, target string) string {
+	label := strings.NewReplacer("[", "\\[", "]", "\\]").Replace(text)
+	if strings.ContainsAny(target, " ()<>") {
+		target = "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(target) + ">"
+	}
+	return "[" + label + "](" + target + ")"
+}
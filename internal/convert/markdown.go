@@ -2,16 +2,38 @@ package convert
 
 import (
 	"strings"
+	"unicode"
 )
 
 const emptySheetMessage = "_No data in this sheet._"
 
 // SheetToMarkdown converts a 2D slice of strings into a Markdown table.
 func SheetToMarkdown(rows [][]string) (string, int, int) {
-	if len(rows) == 0 {
+	return SheetToMarkdownAligned(rows, nil)
+}
+
+// SheetToMarkdownAligned is SheetToMarkdown with per-column alignment in the
+// separator row. Columns beyond len(aligns) are left unaligned.
+func SheetToMarkdownAligned(rows [][]string, aligns []Alignment) (string, int, int) {
+	normalized, maxCols := normalizeRows(rows)
+	if len(normalized) == 0 {
 		return emptySheetMessage, 0, 0
 	}
 
+	lines := make([]string, 0, len(normalized)+1)
+	lines = append(lines, formatRow(normalized[0]))
+	lines = append(lines, formatSeparator(maxCols, aligns))
+
+	for i := 1; i < len(normalized); i++ {
+		lines = append(lines, formatRow(normalized[i]))
+	}
+
+	return strings.Join(lines, "\n"), len(normalized), maxCols
+}
+
+// normalizeRows trims trailing empty cells and rows and pads every row to the
+// widest one. It returns nil when nothing is left.
+func normalizeRows(rows [][]string) ([][]string, int) {
 	trimmedRows := make([][]string, 0, len(rows))
 	maxCols := 0
 	for _, row := range rows {
@@ -23,22 +45,10 @@ func SheetToMarkdown(rows [][]string) (string, int, int) {
 	}
 
 	trimmedRows = trimTrailingEmptyRows(trimmedRows)
-
 	if len(trimmedRows) == 0 || maxCols == 0 {
-		return emptySheetMessage, 0, 0
-	}
-
-	normalized := padRows(trimmedRows, maxCols)
-
-	lines := make([]string, 0, len(normalized)+1)
-	lines = append(lines, formatRow(normalized[0]))
-	lines = append(lines, formatSeparator(maxCols))
-
-	for i := 1; i < len(normalized); i++ {
-		lines = append(lines, formatRow(normalized[i]))
+		return nil, 0
 	}
-
-	return strings.Join(lines, "\n"), len(normalized), maxCols
+	return padRows(trimmedRows, maxCols), maxCols
 }
 
 func trimTrailingEmpty(row []string) []string {
@@ -90,10 +100,21 @@ func formatRow(row []string) string {
 	return "| " + strings.Join(parts, " | ") + " |"
 }
 
-func formatSeparator(width int) string {
+func formatSeparator(width int, aligns []Alignment) string {
 	parts := make([]string, width)
 	for i := range parts {
 		parts[i] = "---"
+		if i >= len(aligns) {
+			continue
+		}
+		switch aligns[i] {
+		case AlignLeft:
+			parts[i] = ":---"
+		case AlignRight:
+			parts[i] = "---:"
+		case AlignCenter:
+			parts[i] = ":---:"
+		}
 	}
 	return "| " + strings.Join(parts, " | ") + " |"
 }
@@ -107,3 +128,80 @@ func escapeCell(value string) string {
 	escaped = strings.ReplaceAll(escaped, "|", "\\|")
 	return escaped
 }
+
+// markdownAppendix returns the notes section and footnote definitions that
+// follow a sheet's table, preceded by a blank line.
+func markdownAppendix(notes []Note, footnotes []Footnote) string {
+	appendix := ""
+	if len(notes) > 0 {
+		appendix += "\n\n" + formatNotes(notes)
+	}
+	if len(footnotes) > 0 {
+		appendix += "\n\n" + formatFootnotes(footnotes)
+	}
+	return appendix
+}
+
+func formatFootnotes(footnotes []Footnote) string {
+	lines := make([]string, len(footnotes))
+	for i, note := range footnotes {
+		text := strings.ReplaceAll(strings.ReplaceAll(note.Text, "\r\n", "\n"), "\n", "\n    ")
+		lines[i] = "[^" + note.Label + "]: " + text
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatNotes(notes []Note) string {
+	lines := []string{"### Notes", ""}
+	for _, note := range notes {
+		text := strings.ReplaceAll(strings.ReplaceAll(noteText(note), "\r\n", "\n"), "\n", "\n  ")
+		lines = append(lines, "- **"+note.Cell+"**: "+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headingAnchor returns the GitHub-style anchor of a "## name" heading:
+// lowercase, punctuation dropped, spaces turned into hyphens.
+func headingAnchor(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r == ' ':
+			builder.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// footnoteLabel builds a label that stays unique when several sheets are
+// combined into one document, e.g. "Sales-B2".
+func footnoteLabel(sheetName, cellRef, suffix string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '\t':
+			return '-'
+		case r == ']' || r == '[' || r == '^':
+			return -1
+		}
+		return r
+	}, sheetName) + "-" + cellRef
+	if suffix != "" {
+		label += "-" + suffix
+	}
+	return label
+}
+
+// codeSpan wraps value in backticks, using a longer fence when value
+// contains backticks itself.
+func codeSpan(value string) string {
+	fence := "`"
+	for strings.Contains(value, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`") {
+		return fence + " " + value + " " + fence
+	}
+	return fence + value + fence
+}
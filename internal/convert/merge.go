@@ -0,0 +1,205 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MergeRange is a merged cell area in 0-based, inclusive row and column
+// positions. In SheetMeta they index the rendered rows; during extraction
+// they are sheet positions.
+type MergeRange struct {
+	Ref    string
+	Row    int
+	Col    int
+	EndRow int
+	EndCol int
+}
+
+func (m MergeRange) rowSpan() int { return m.EndRow - m.Row + 1 }
+func (m MergeRange) colSpan() int { return m.EndCol - m.Col + 1 }
+
+// readMerges returns the merged ranges of a sheet in workbook order.
+func readMerges(file *excelize.File, sheetName string) ([]MergeRange, error) {
+	cells, err := file.GetMergeCells(sheetName, true)
+	if err != nil {
+		return nil, err
+	}
+	merges := make([]MergeRange, 0, len(cells))
+	for _, cell := range cells {
+		if merge, ok := parseMerge(cell.GetStartAxis() + ":" + cell.GetEndAxis()); ok {
+			merges = append(merges, merge)
+		}
+	}
+	return merges, nil
+}
+
+// parseMerge parses a merged range reference such as "A1:B2".
+func parseMerge(ref string) (MergeRange, bool) {
+	start, end, found := strings.Cut(ref, ":")
+	if !found {
+		end = start
+	}
+	startCol, startRow, err := excelize.CellNameToCoordinates(start)
+	if err != nil {
+		return MergeRange{}, false
+	}
+	endCol, endRow, err := excelize.CellNameToCoordinates(end)
+	if err != nil {
+		return MergeRange{}, false
+	}
+	return MergeRange{
+		Ref:    start + ":" + end,
+		Row:    startRow - 1,
+		Col:    startCol - 1,
+		EndRow: endRow - 1,
+		EndCol: endCol - 1,
+	}, true
+}
+
+// applyMerges applies the merge strategy to the extracted rows and returns
+// the warning describing what happened.
+func applyMerges(data *sheetData, merges []MergeRange, strategy MergeStrategy) string {
+	if len(merges) == 0 {
+		return ""
+	}
+
+	switch strategy {
+	case MergeFill:
+		for _, merge := range merges {
+			fillMerge(data, merge)
+		}
+		return "Merged cells were filled with their top-left value: " + mergeRefs(merges)
+	case MergeHTML:
+		data.merges = merges
+		return "Merged cells are rendered as an HTML table: " + mergeRefs(merges)
+	default:
+		return "Merged cells were flattened to their top-left value: " + mergeRefs(merges)
+	}
+}
+
+func fillMerge(data *sheetData, merge MergeRange) {
+	if merge.Row >= len(data.rows) {
+		return
+	}
+	value := ""
+	if merge.Col < len(data.rows[merge.Row]) {
+		value = data.rows[merge.Row][merge.Col]
+	}
+	if value == "" {
+		return
+	}
+	plain, decorated := data.plain[[2]int{merge.Row + 1, merge.Col + 1}]
+	formula, isFormula := data.formulaText[[2]int{merge.Row + 1, merge.Col + 1}]
+	for row := merge.Row; row <= merge.EndRow && row < len(data.rows); row++ {
+		if len(data.rows[row]) <= merge.EndCol {
+			extended := make([]string, merge.EndCol+1)
+			copy(extended, data.rows[row])
+			data.rows[row] = extended
+		}
+		for col := merge.Col; col <= merge.EndCol; col++ {
+			data.rows[row][col] = value
+			if decorated {
+				data.setPlain(row+1, col+1, plain)
+			}
+			if isFormula {
+				data.formulaText[[2]int{row + 1, col + 1}] = formula
+			}
+		}
+		if len(data.rows[row]) > data.colCount {
+			data.colCount = len(data.rows[row])
+		}
+	}
+}
+
+func mergeRefs(merges []MergeRange) string {
+	refs := []string{}
+	for i, merge := range merges {
+		if i == maxCellWarnings {
+			refs = append(refs, fmt.Sprintf("and %d more", len(merges)-maxCellWarnings))
+			break
+		}
+		refs = append(refs, merge.Ref)
+	}
+	return strings.Join(refs, ", ") + "."
+}
+
+// projectMerges maps the sheet merges onto the rendered rows and columns.
+// A merge survives when the rows and columns it covers are still present
+// and contiguous; it is clipped to the part that remains.
+func projectMerges(data *sheetData) []MergeRange {
+	if len(data.merges) == 0 {
+		return nil
+	}
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+
+	projected := []MergeRange{}
+	for _, merge := range data.merges {
+		firstRow, lastRow, okRows := projectSpan(len(data.rows), func(i int) int { return data.rowNums[i] }, merge.Row+1, merge.EndRow+1)
+		firstCol, lastCol, okCols := projectSpan(width, data.colNum, merge.Col+1, merge.EndCol+1)
+		if !okRows || !okCols {
+			continue
+		}
+		projected = append(projected, MergeRange{
+			Ref:    merge.Ref,
+			Row:    firstRow,
+			Col:    firstCol,
+			EndRow: lastRow,
+			EndCol: lastCol,
+		})
+	}
+	return projected
+}
+
+// projectSpan finds the rendered positions whose sheet number lies in
+// [from, to] and reports whether they form one contiguous run.
+func projectSpan(count int, sheetNum func(int) int, from, to int) (int, int, bool) {
+	first, last, matches := -1, -1, 0
+	for i := 0; i < count; i++ {
+		if n := sheetNum(i); n >= from && n <= to {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			matches++
+		}
+	}
+	return first, last, first >= 0 && matches == last-first+1
+}
+
+// spanGrid maps merges onto a rows x cols grid. Anchor cells get their spans;
+// cells covered by a merge are marked so renderers can skip them. Spans are
+// clipped to the grid, and merges starting in the header row do not extend
+// into the body.
+func spanGrid(merges []MergeRange, rows, cols int) (map[[2]int]MergeRange, map[[2]int]bool) {
+	anchors := map[[2]int]MergeRange{}
+	covered := map[[2]int]bool{}
+	for _, merge := range merges {
+		if merge.Row >= rows || merge.Col >= cols {
+			continue
+		}
+		clipped := merge
+		clipped.EndRow = min(clipped.EndRow, rows-1)
+		clipped.EndCol = min(clipped.EndCol, cols-1)
+		if clipped.Row == 0 {
+			clipped.EndRow = 0
+		}
+		if covered[[2]int{clipped.Row, clipped.Col}] {
+			continue
+		}
+		anchors[[2]int{clipped.Row, clipped.Col}] = clipped
+		for row := clipped.Row; row <= clipped.EndRow; row++ {
+			for col := clipped.Col; col <= clipped.EndCol; col++ {
+				if row != clipped.Row || col != clipped.Col {
+					covered[[2]int{row, col}] = true
+				}
+			}
+		}
+	}
+	return anchors, covered
+}
@@ -0,0 +1,110 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// namedObject is an Excel Table or a defined name referring to one area of
+// a sheet.
+type namedObject struct {
+	name string
+	area cellRange
+	// columns, headerRows and totalsRows come from the table definition and
+	// are unset for defined names.
+	table      bool
+	columns    []string
+	headerRows int
+	totalsRows int
+}
+
+// tableDefinition holds the parts of xl/tables/tableN.xml that GetTables
+// does not expose.
+type tableDefinition struct {
+	Name           string `xml:"name,attr"`
+	HeaderRowCount *int   `xml:"headerRowCount,attr"`
+	TotalsRowCount int    `xml:"totalsRowCount,attr"`
+	Columns        []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"tableColumns>tableColumn"`
+}
+
+// readNamedObjects returns the Excel Tables and single-area defined names of
+// the workbook keyed by sheet name, tables first. Built-in names such as
+// print areas and names holding constants or formulas are ignored.
+func readNamedObjects(file *excelize.File) map[string][]namedObject {
+	definitions := map[string]tableDefinition{}
+	file.Pkg.Range(func(key, value any) bool {
+		path, _ := key.(string)
+		content, _ := value.([]byte)
+		if !strings.HasPrefix(path, "xl/tables/") || !strings.HasSuffix(path, ".xml") {
+			return true
+		}
+		var definition tableDefinition
+		if err := xml.NewDecoder(bytes.NewReader(content)).Decode(&definition); err == nil {
+			definitions[definition.Name] = definition
+		}
+		return true
+	})
+
+	objects := map[string][]namedObject{}
+	for _, sheetName := range file.GetSheetList() {
+		tables, err := file.GetTables(sheetName)
+		if err != nil {
+			continue
+		}
+		for _, table := range tables {
+			_, area, err := parseRange(table.Range)
+			if err != nil {
+				continue
+			}
+			object := namedObject{name: table.Name, area: area, table: true, headerRows: 1}
+			if definition, ok := definitions[table.Name]; ok {
+				for _, column := range definition.Columns {
+					object.columns = append(object.columns, column.Name)
+				}
+				if definition.HeaderRowCount != nil {
+					object.headerRows = *definition.HeaderRowCount
+				}
+				object.totalsRows = definition.TotalsRowCount
+			}
+			objects[sheetName] = append(objects[sheetName], object)
+		}
+	}
+
+	for _, name := range file.GetDefinedName() {
+		if strings.HasPrefix(name.Name, "_xlnm.") || strings.Contains(name.RefersTo, ",") {
+			continue
+		}
+		sheet, area, err := parseRange(name.RefersTo)
+		if err != nil || sheet == "" {
+			continue
+		}
+		if index, err := file.GetSheetIndex(sheet); err != nil || index < 0 {
+			continue
+		}
+		objects[sheet] = append(objects[sheet], namedObject{name: name.Name, area: area})
+	}
+	return objects
+}
+
+// objectData crops data to object and, for tables, replaces the header with
+// the declared column names.
+func objectData(data *sheetData, object namedObject) *sheetData {
+	part := data.crop(object.area)
+	if !object.table || len(object.columns) == 0 {
+		return part
+	}
+	header := append([]string{}, object.columns...)
+	part.header = object.columns
+	if object.headerRows == 0 || len(part.rows) == 0 {
+		part.rows = append([][]string{header}, part.rows...)
+		part.rowNums = append([]int{0}, part.rowNums...)
+		return part
+	}
+	part.rows[0] = header
+	return part
+}
@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// OutlineMode selects how row outline levels (grouped rows) are output.
+type OutlineMode string
+
+const (
+	// OutlineNone ignores outline levels (default).
+	OutlineNone OutlineMode = "none"
+	// OutlineIndent indents the first column with two non-breaking spaces
+	// per level.
+	OutlineIndent OutlineMode = "indent"
+	// OutlineArrow prefixes the first column of grouped rows with "↳ ",
+	// indented by level.
+	OutlineArrow OutlineMode = "arrow"
+	// OutlineList renders Markdown as a nested bulleted list instead of a
+	// table. Other formats keep their table shape.
+	OutlineList OutlineMode = "list"
+)
+
+const outlineIndent = "\u00a0\u00a0"
+
+// readOutline records the outline level of every extracted row that has one.
+func readOutline(file *excelize.File, sheetName string, data *sheetData) error {
+	data.outline = map[int]int{}
+	for row := 1; row <= data.rowCount; row++ {
+		level, err := file.GetRowOutlineLevel(sheetName, row)
+		if err != nil {
+			return err
+		}
+		if level > 0 {
+			data.outline[row] = int(level)
+		}
+	}
+	return nil
+}
+
+// indentOutline prefixes the first cell of grouped data rows according to
+// mode. rows holds the cells of data as they are rendered.
+func indentOutline(data *sheetData, rows [][]string, mode OutlineMode) {
+	for i := 1; i < len(rows); i++ {
+		level := data.outline[data.rowNums[i]]
+		if level == 0 {
+			continue
+		}
+		prefix := strings.Repeat(outlineIndent, level)
+		if mode == OutlineArrow {
+			prefix = strings.Repeat(outlineIndent, level-1) + "↳ "
+		}
+		if len(rows[i]) == 0 {
+			rows[i] = []string{""}
+		}
+		rows[i][0] = prefix + rows[i][0]
+	}
+}
+
+// outlineLevels returns the outline level of each row of data.
+func outlineLevels(data *sheetData) []int {
+	levels := make([]int, len(data.rows))
+	for i, rowNum := range data.rowNums {
+		if i < len(levels) {
+			levels[i] = data.outline[rowNum]
+		}
+	}
+	return levels
+}
+
+// formatOutlineList renders the data rows as a nested bulleted list. The
+// first non-empty cell is the item; the other cells follow as
+// "Header: value" pairs. A level never nests more than one step deeper than
+// the item before it.
+func formatOutlineList(rows [][]string, levels []int) string {
+	normalized, _ := normalizeRows(rows)
+	if len(normalized) < 2 {
+		markdown, _, _ := SheetToMarkdown(rows)
+		return markdown
+	}
+	header := normalized[0]
+
+	lines := []string{}
+	depth := -1
+	for i, row := range normalized[1:] {
+		level := 0
+		if i+1 < len(levels) {
+			level = levels[i+1]
+		}
+		depth = min(level, depth+1)
+
+		label, details := "", []string{}
+		for col, cell := range row {
+			cell = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(cell, "\r\n", "\n"), "\n", " "))
+			switch {
+			case cell == "":
+			case label == "":
+				label = cell
+			case strings.TrimSpace(header[col]) != "":
+				details = append(details, strings.TrimSpace(header[col])+": "+cell)
+			default:
+				details = append(details, cell)
+			}
+		}
+		if label == "" {
+			continue
+		}
+		item := strings.Repeat("  ", depth) + "- " + label
+		if len(details) > 0 {
+			item += " (" + strings.Join(details, ", ") + ")"
+		}
+		lines = append(lines, item)
+	}
+	if len(lines) == 0 {
+		return emptySheetMessage
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,126 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cellRange is an inclusive, 1-based area of a sheet. A zero lastRow or
+// lastCol leaves the range open to the end of the sheet data, as in "A:C"
+// or "2:10".
+type cellRange struct {
+	firstRow, firstCol int
+	lastRow, lastCol   int
+}
+
+// String formats r in A1 notation, e.g. "B3:F20", "A:C" or "2:10".
+func (r cellRange) String() string {
+	switch {
+	case r.lastRow == 0 && r.firstRow <= 1:
+		first, _ := excelize.ColumnNumberToName(r.firstCol)
+		last, _ := excelize.ColumnNumberToName(r.lastCol)
+		return first + ":" + last
+	case r.lastCol == 0 && r.firstCol <= 1:
+		return fmt.Sprintf("%d:%d", r.firstRow, r.lastRow)
+	}
+	first, _ := excelize.CoordinatesToCellName(r.firstCol, r.firstRow)
+	last, _ := excelize.CoordinatesToCellName(r.lastCol, r.lastRow)
+	return first + ":" + last
+}
+
+// parseRange parses an A1 reference such as "B3:F20", "$A$1", "A:C", "2:10"
+// or "'Q1 Sales'!A1:C9" and returns the sheet name, if any, and the area.
+func parseRange(ref string) (string, cellRange, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "=")
+	sheet := ""
+	if index := strings.LastIndex(ref, "!"); index >= 0 {
+		sheet, ref = ref[:index], ref[index+1:]
+		if strings.HasPrefix(sheet, "'") && strings.HasSuffix(sheet, "'") && len(sheet) > 1 {
+			sheet = strings.ReplaceAll(sheet[1:len(sheet)-1], "''", "'")
+		}
+	}
+	from, to, ok := strings.Cut(strings.ReplaceAll(ref, "$", ""), ":")
+	if !ok {
+		to = from
+	}
+	firstCol, firstRow, errFirst := splitCellRef(from)
+	lastCol, lastRow, errLast := splitCellRef(to)
+	if errFirst != nil || errLast != nil || (firstCol == 0) != (lastCol == 0) || (firstRow == 0) != (lastRow == 0) {
+		return "", cellRange{}, fmt.Errorf("%w: invalid range %q", ErrInvalidOption, ref)
+	}
+
+	r := cellRange{
+		firstRow: max(min(firstRow, lastRow), 1),
+		firstCol: max(min(firstCol, lastCol), 1),
+		lastRow:  max(firstRow, lastRow),
+		lastCol:  max(firstCol, lastCol),
+	}
+	return sheet, r, nil
+}
+
+// splitCellRef splits "B3" into column 2 and row 3. Either part may be
+// missing ("B" or "3"), in which case it is returned as 0.
+func splitCellRef(ref string) (int, int, error) {
+	letters := strings.TrimRightFunc(ref, func(r rune) bool { return r >= '0' && r <= '9' })
+	digits := ref[len(letters):]
+	if letters == "" && digits == "" {
+		return 0, 0, fmt.Errorf("empty cell reference")
+	}
+	col, row := 0, 0
+	if letters != "" {
+		number, err := excelize.ColumnNameToNumber(letters)
+		if err != nil {
+			return 0, 0, err
+		}
+		col = number
+	}
+	if digits != "" {
+		if _, err := fmt.Sscan(digits, &row); err != nil || row < 1 || row > excelize.TotalRows {
+			return 0, 0, fmt.Errorf("invalid row %q", digits)
+		}
+	}
+	return col, row, nil
+}
+
+// clipRow blanks the cells of row rowNum that fall outside r. Rows outside
+// r come back empty.
+func (r cellRange) clipRow(cols []string, rowNum int) []string {
+	if rowNum < r.firstRow || (r.lastRow > 0 && rowNum > r.lastRow) {
+		return nil
+	}
+	if r.lastCol > 0 && len(cols) > r.lastCol {
+		cols = cols[:r.lastCol]
+	}
+	for i := 0; i < min(r.firstCol-1, len(cols)); i++ {
+		cols[i] = ""
+	}
+	return cols
+}
+
+// contains reports whether the cell at 1-based col and row lies inside r.
+func (r cellRange) contains(col, row int) bool {
+	return row >= r.firstRow && col >= r.firstCol &&
+		(r.lastRow == 0 || row <= r.lastRow) && (r.lastCol == 0 || col <= r.lastCol)
+}
+
+// crop returns the part of data inside r. data must still be laid out as
+// extracted, with rows[i] holding sheet row i+1.
+func (data *sheetData) crop(r cellRange) *sheetData {
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+	b := block{top: r.firstRow - 1, left: r.firstCol - 1, bottom: len(data.rows) - 1, right: width - 1}
+	if r.lastRow > 0 {
+		b.bottom = min(b.bottom, r.lastRow-1)
+	}
+	if r.lastCol > 0 {
+		b.right = min(b.right, r.lastCol-1)
+	}
+	if b.top > b.bottom || b.left > b.right {
+		return &sheetData{rows: [][]string{}, rowNums: []int{}, colNums: []int{}, merges: data.merges}
+	}
+	return data.sub(b)
+}
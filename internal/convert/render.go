@@ -0,0 +1,461 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Output formats understood by RendererFor.
+const (
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatHTML     = "html"
+	FormatAsciiDoc = "asciidoc"
+)
+
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// SheetMeta describes the sheet being rendered.
+type SheetMeta struct {
+	Name  string
+	Index int
+	// Align holds per-column alignment when detection or forced alignment
+	// is enabled; it is nil otherwise.
+	Align []Alignment
+	// Footnotes are referenced from cells as [^Label]. Only Markdown output
+	// has them.
+	Footnotes []Footnote
+	// Merges are set with the HTML merge strategy; renderers that support
+	// spans should honor them.
+	Merges []MergeRange
+	// Notes are cell comments to list under the sheet.
+	Notes []Note
+	// Outline holds the outline level of each row when Markdown is to be
+	// rendered as a nested list (OutlineList); it is nil otherwise.
+	Outline []int
+	// Table is the name or range (e.g. "Sheet1!B3:F20") of the table being
+	// rendered when the sheet was split into several tables.
+	Table string
+}
+
+// Footnote is a Markdown footnote definition attached to a sheet.
+type Footnote struct {
+	Label string
+	Text  string
+}
+
+// Renderer turns extracted sheet rows into an output document.
+type Renderer interface {
+	// Render formats the rows of one sheet. The first row is the header.
+	// Renderers other than MarkdownRenderer get the cells without the
+	// Markdown markup of RichText, Hyperlinks and the formula modes, with
+	// formula text in place of stored values under FormulaText.
+	Render(rows [][]string, meta SheetMeta) (string, error)
+	// Combine joins already rendered sheets (SheetResult.Output) into one
+	// document.
+	Combine(sheets []SheetResult) string
+}
+
+// TableJoiner is implemented by renderers that can join the tables of one
+// sheet (TableResult.Output) under their own sub-headings. Renderers without
+// it get the tables separated by blank lines.
+type TableJoiner interface {
+	JoinTables(tables []TableResult) string
+}
+
+func joinTables(renderer Renderer, tables []TableResult) string {
+	if joiner, ok := renderer.(TableJoiner); ok {
+		return joiner.JoinTables(tables)
+	}
+	outputs := make([]string, len(tables))
+	for i, table := range tables {
+		outputs[i] = table.Output
+	}
+	return strings.Join(outputs, "\n\n")
+}
+
+// Formats lists the built-in output formats.
+func Formats() []string {
+	return []string{FormatMarkdown, FormatCSV, FormatTSV, FormatJSON, FormatJSONL, FormatHTML, FormatAsciiDoc}
+}
+
+// RendererFor returns the built-in renderer for format. An empty format
+// selects Markdown.
+func RendererFor(format string) (Renderer, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatMarkdown, "md":
+		return MarkdownRenderer{}, nil
+	case FormatCSV:
+		return DelimitedRenderer{Comma: ','}, nil
+	case FormatTSV:
+		return DelimitedRenderer{Comma: '\t'}, nil
+	case FormatJSON:
+		return JSONRenderer{}, nil
+	case FormatJSONL, "ndjson":
+		return JSONLinesRenderer{}, nil
+	case FormatHTML:
+		return HTMLRenderer{}, nil
+	case FormatAsciiDoc, "adoc":
+		return AsciiDocRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// MarkdownRenderer renders GitHub-flavored Markdown pipe tables.
+type MarkdownRenderer struct{}
+
+// Render falls back to an HTML table when meta carries merges, and renders
+// a nested list when it carries outline levels.
+func (MarkdownRenderer) Render(rows [][]string, meta SheetMeta) (string, error) {
+	markdown, _, _ := SheetToMarkdownAligned(rows, meta.Align)
+	switch {
+	case meta.Outline != nil:
+		markdown = formatOutlineList(rows, meta.Outline)
+	case len(meta.Merges) > 0:
+		markdown, _ = HTMLRenderer{}.Render(rows, meta)
+	}
+	return markdown + markdownAppendix(meta.Notes, meta.Footnotes), nil
+}
+
+// JoinTables puts each table under a heading with its name or range, e.g.
+// "### Sheet1!B3:F20".
+func (MarkdownRenderer) JoinTables(tables []TableResult) string {
+	blocks := make([]string, len(tables))
+	for i, table := range tables {
+		blocks[i] = "### " + table.title() + "\n\n" + table.Markdown
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (MarkdownRenderer) Combine(sheets []SheetResult) string {
+	return CombineMarkdown(sheets)
+}
+
+// DelimitedRenderer renders CSV (Comma ',') or TSV (Comma '\t').
+type DelimitedRenderer struct {
+	Comma rune
+}
+
+func (r DelimitedRenderer) Render(rows [][]string, _ SheetMeta) (string, error) {
+	normalized, _ := normalizeRows(rows)
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if r.Comma != 0 {
+		writer.Comma = r.Comma
+	}
+	if err := writer.WriteAll(normalized); err != nil {
+		return "", fmt.Errorf("render delimited: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Combine returns the output of the only sheet. CSV and TSV hold a single
+// table, so several sheets combine to nothing and stay available through
+// SheetResult.Output.
+func (r DelimitedRenderer) Combine(sheets []SheetResult) string {
+	if len(sheets) != 1 {
+		return ""
+	}
+	return sheets[0].Output
+}
+
+// JoinTables returns the output of the only table; a sheet split into
+// several tables keeps them in TableResult.Output only.
+func (r DelimitedRenderer) JoinTables(tables []TableResult) string {
+	if len(tables) != 1 {
+		return ""
+	}
+	return tables[0].Output
+}
+
+// JSONRenderer renders an array of objects keyed by the header row.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(rows [][]string, _ SheetMeta) (string, error) {
+	objects := rowObjects(rows)
+	return "[" + strings.Join(objects, ",") + "]", nil
+}
+
+// Combine returns an array with one {"name", "rows"} entry per sheet.
+func (JSONRenderer) Combine(sheets []SheetResult) string {
+	entries := []string{}
+	for _, sheet := range sheets {
+		if sheet.Name == "" {
+			continue
+		}
+		fields := []string{`"name":` + jsonString(sheet.Name)}
+		if sheet.Error != "" {
+			fields = append(fields, `"error":`+jsonString(sheet.Error))
+		} else {
+			output := sheet.Output
+			if output == "" {
+				output = "[]"
+			}
+			key := `"rows":`
+			if len(sheet.Tables) > 0 {
+				key = `"tables":`
+			}
+			fields = append(fields, key+output)
+		}
+		if len(sheet.Warnings) > 0 {
+			warnings, _ := json.Marshal(sheet.Warnings)
+			fields = append(fields, `"warnings":`+string(warnings))
+		}
+		entries = append(entries, "{"+strings.Join(fields, ",")+"}")
+	}
+	return "[" + strings.Join(entries, ",") + "]"
+}
+
+// JoinTables returns an array with one {"name", "range", "rows"} entry per
+// table; name is omitted for unnamed tables.
+func (JSONRenderer) JoinTables(tables []TableResult) string {
+	entries := make([]string, len(tables))
+	for i, table := range tables {
+		fields := []string{`"range":` + jsonString(table.Range), `"rows":` + table.Output}
+		if table.Name != "" {
+			fields = append([]string{`"name":` + jsonString(table.Name)}, fields...)
+		}
+		entries[i] = "{" + strings.Join(fields, ",") + "}"
+	}
+	return "[" + strings.Join(entries, ",") + "]"
+}
+
+// JSONLinesRenderer renders one JSON object per data row.
+type JSONLinesRenderer struct{}
+
+func (JSONLinesRenderer) Render(rows [][]string, _ SheetMeta) (string, error) {
+	objects := rowObjects(rows)
+	if len(objects) == 0 {
+		return "", nil
+	}
+	return strings.Join(objects, "\n") + "\n", nil
+}
+
+// Combine wraps each row as {"sheet": name, "row": {...}}.
+func (JSONLinesRenderer) Combine(sheets []SheetResult) string {
+	var builder strings.Builder
+	for _, sheet := range sheets {
+		if sheet.Name == "" || sheet.Error != "" {
+			continue
+		}
+		name := jsonString(sheet.Name)
+		for _, line := range strings.Split(strings.TrimSuffix(sheet.Output, "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			builder.WriteString(`{"sheet":` + name + `,"row":` + line + "}\n")
+		}
+	}
+	return builder.String()
+}
+
+// JoinTables wraps each row as {"table": name or range, "row": {...}}.
+func (JSONLinesRenderer) JoinTables(tables []TableResult) string {
+	var builder strings.Builder
+	for _, table := range tables {
+		name := jsonString(table.title())
+		for _, line := range strings.Split(strings.TrimSuffix(table.Output, "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			builder.WriteString(`{"table":` + name + `,"row":` + line + "}\n")
+		}
+	}
+	return builder.String()
+}
+
+// HTMLRenderer renders an HTML <table> with a <thead> header row.
+type HTMLRenderer struct{}
+
+// Render honors meta.Merges with colspan and rowspan attributes.
+func (HTMLRenderer) Render(rows [][]string, meta SheetMeta) (string, error) {
+	normalized, _ := normalizeRows(rows)
+	if len(normalized) == 0 {
+		return "<p><em>No data in this sheet.</em></p>", nil
+	}
+
+	anchors, covered := spanGrid(meta.Merges, len(normalized), len(normalized[0]))
+	var builder strings.Builder
+	builder.WriteString("<table>\n<thead>\n")
+	writeHTMLRow(&builder, normalized[0], 0, "th", anchors, covered)
+	builder.WriteString("</thead>\n<tbody>\n")
+	for i, row := range normalized[1:] {
+		writeHTMLRow(&builder, row, i+1, "td", anchors, covered)
+	}
+	builder.WriteString("</tbody>\n</table>")
+	return builder.String(), nil
+}
+
+func (HTMLRenderer) Combine(sheets []SheetResult) string {
+	blocks := []string{}
+	for _, sheet := range sheets {
+		if sheet.Name == "" {
+			continue
+		}
+		blocks = append(blocks, "<h2>"+html.EscapeString(sheet.Name)+"</h2>")
+		if sheet.Error != "" {
+			blocks = append(blocks, `<p class="error">Error: `+html.EscapeString(sheet.Error)+"</p>")
+			continue
+		}
+		for _, warning := range sheet.Warnings {
+			blocks = append(blocks, `<p class="warning">Warning: `+html.EscapeString(warning)+"</p>")
+		}
+		blocks = append(blocks, sheet.Output)
+	}
+	return strings.Join(blocks, "\n")
+}
+
+func (HTMLRenderer) JoinTables(tables []TableResult) string {
+	blocks := []string{}
+	for _, table := range tables {
+		blocks = append(blocks, "<h3>"+html.EscapeString(table.title())+"</h3>", table.Output)
+	}
+	return strings.Join(blocks, "\n")
+}
+
+func writeHTMLRow(builder *strings.Builder, row []string, rowIndex int, tag string, anchors map[[2]int]MergeRange, covered map[[2]int]bool) {
+	builder.WriteString("<tr>")
+	for col, cell := range row {
+		key := [2]int{rowIndex, col}
+		if covered[key] {
+			continue
+		}
+		attrs := ""
+		if merge, ok := anchors[key]; ok {
+			if span := merge.colSpan(); span > 1 {
+				attrs += fmt.Sprintf(` colspan="%d"`, span)
+			}
+			if span := merge.rowSpan(); span > 1 {
+				attrs += fmt.Sprintf(` rowspan="%d"`, span)
+			}
+		}
+		builder.WriteString("<" + tag + attrs + ">" + escapeHTMLCell(cell) + "</" + tag + ">")
+	}
+	builder.WriteString("</tr>\n")
+}
+
+func escapeHTMLCell(value string) string {
+	escaped := html.EscapeString(strings.ReplaceAll(value, "\r\n", "\n"))
+	return strings.ReplaceAll(escaped, "\n", "<br>")
+}
+
+// AsciiDocRenderer renders an AsciiDoc table with a header row.
+type AsciiDocRenderer struct{}
+
+func (AsciiDocRenderer) Render(rows [][]string, _ SheetMeta) (string, error) {
+	normalized, _ := normalizeRows(rows)
+	if len(normalized) == 0 {
+		return "_No data in this sheet._", nil
+	}
+
+	lines := []string{`[options="header"]`, "|==="}
+	for _, row := range normalized {
+		parts := make([]string, len(row))
+		for i, cell := range row {
+			parts[i] = "|" + escapeAsciiDocCell(cell)
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+	lines = append(lines, "|===")
+	return strings.Join(lines, "\n"), nil
+}
+
+func (AsciiDocRenderer) Combine(sheets []SheetResult) string {
+	blocks := []string{}
+	for _, sheet := range sheets {
+		if sheet.Name == "" {
+			continue
+		}
+		blocks = append(blocks, "== "+sheet.Name, "")
+		if sheet.Error != "" {
+			blocks = append(blocks, "CAUTION: "+sheet.Error, "")
+			continue
+		}
+		for _, warning := range sheet.Warnings {
+			blocks = append(blocks, "WARNING: "+warning, "")
+		}
+		blocks = append(blocks, sheet.Output, "")
+	}
+	return strings.Join(blocks, "\n")
+}
+
+func (AsciiDocRenderer) JoinTables(tables []TableResult) string {
+	blocks := []string{}
+	for _, table := range tables {
+		blocks = append(blocks, "=== "+table.title(), "", table.Output)
+	}
+	return strings.Join(blocks, "\n")
+}
+
+func escapeAsciiDocCell(value string) string {
+	escaped := strings.ReplaceAll(value, "\r\n", "\n")
+	escaped = strings.ReplaceAll(escaped, "|", "\\|")
+	return strings.ReplaceAll(escaped, "\n", " +\n")
+}
+
+// rowObjects encodes every data row as a JSON object keyed by the header,
+// keeping column order.
+func rowObjects(rows [][]string) []string {
+	normalized, _ := normalizeRows(rows)
+	if len(normalized) == 0 {
+		return []string{}
+	}
+
+	keys := headerKeys(normalized[0])
+	objects := make([]string, 0, len(normalized)-1)
+	for _, row := range normalized[1:] {
+		fields := make([]string, len(row))
+		for i, cell := range row {
+			fields[i] = jsonString(keys[i]) + ":" + jsonString(cell)
+		}
+		objects = append(objects, "{"+strings.Join(fields, ",")+"}")
+	}
+	return objects
+}
+
+// headerKeys makes header cells usable as object keys: blank headers fall
+// back to the column letter and duplicates get the first numeric suffix
+// that no other column uses or is named.
+func headerKeys(header []string) []string {
+	keys := make([]string, len(header))
+	named := map[string]bool{}
+	for i, cell := range header {
+		keys[i] = strings.TrimSpace(cell)
+		if keys[i] == "" {
+			keys[i], _ = excelize.ColumnNumberToName(i + 1)
+		}
+		named[keys[i]] = true
+	}
+	used := map[string]bool{}
+	suffix := map[string]int{}
+	for i, key := range keys {
+		if used[key] {
+			n := max(suffix[key], 1)
+			candidate := key
+			for used[candidate] || named[candidate] {
+				n++
+				candidate = fmt.Sprintf("%s_%d", key, n)
+			}
+			suffix[key] = n
+			keys[i] = candidate
+		}
+		used[keys[i]] = true
+	}
+	return keys
+}
+
+func jsonString(value string) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,148 @@
+package convert
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var renderRows = [][]string{
+	{"Name", "Note", ""},
+	{"Asha", "a, \"b\"", ""},
+	{"Ben", "x|y\nz"},
+}
+
+func TestDelimitedRenderer(t *testing.T) {
+	csvOut, err := DelimitedRenderer{Comma: ','}.Render(renderRows, SheetMeta{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Name,Note\nAsha,\"a, \"\"b\"\"\"\nBen,\"x|y\nz\"\n"
+	if csvOut != expected {
+		t.Fatalf("unexpected csv:\n%q", csvOut)
+	}
+
+	tsvOut, _ := DelimitedRenderer{Comma: '\t'}.Render(renderRows[:1], SheetMeta{})
+	if tsvOut != "Name\tNote\n" {
+		t.Fatalf("unexpected tsv: %q", tsvOut)
+	}
+}
+
+func TestJSONRenderers(t *testing.T) {
+	rows := [][]string{{"Name", "", "Name"}, {"Asha", "1", "2"}}
+	out, _ := JSONRenderer{}.Render(rows, SheetMeta{})
+	expected := `[{"Name":"Asha","B":"1","Name_2":"2"}]`
+	if out != expected {
+		t.Fatalf("unexpected json: %s", out)
+	}
+
+	keys := headerKeys([]string{"Name", "Name", "Name_2", "Name", "F", ""})
+	if expected := []string{"Name", "Name_3", "Name_2", "Name_4", "F", "F_2"}; !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("got keys %q, expected %q", keys, expected)
+	}
+
+	lines, _ := JSONLinesRenderer{}.Render(renderRows, SheetMeta{})
+	if strings.Count(lines, "\n") != 2 || !strings.HasPrefix(lines, `{"Name":"Asha",`) {
+		t.Fatalf("unexpected jsonl:\n%s", lines)
+	}
+
+	empty, _ := JSONRenderer{}.Render(nil, SheetMeta{})
+	if empty != "[]" {
+		t.Fatalf("expected empty array, got %s", empty)
+	}
+}
+
+func TestHTMLAndAsciiDocRenderers(t *testing.T) {
+	htmlOut, _ := HTMLRenderer{}.Render([][]string{{"<b>"}, {"a\nb"}}, SheetMeta{})
+	if !strings.Contains(htmlOut, "<th>&lt;b&gt;</th>") || !strings.Contains(htmlOut, "<td>a<br>b</td>") {
+		t.Fatalf("unexpected html:\n%s", htmlOut)
+	}
+
+	adoc, _ := AsciiDocRenderer{}.Render([][]string{{"A", "B"}, {"x|y", "z"}}, SheetMeta{})
+	expected := "[options=\"header\"]\n|===\n|A |B\n|x\\|y |z\n|==="
+	if adoc != expected {
+		t.Fatalf("unexpected asciidoc:\n%s", adoc)
+	}
+}
+
+func TestConvertWithFormat(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Name")
+	file.SetCellValue("Sheet1", "A2", "Asha")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{Format: "csv"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Format != FormatCSV || res.Sheets[0].Output != "Name\nAsha\n" {
+		t.Fatalf("unexpected output: %+v", res.Sheets[0])
+	}
+	if res.Sheets[0].Markdown == "" || res.CombinedOutput != "Name\nAsha\n" {
+		t.Fatalf("unexpected combined output: %q", res.CombinedOutput)
+	}
+	if _, err := csv.NewReader(strings.NewReader(res.CombinedOutput)).ReadAll(); err != nil {
+		t.Fatalf("combined output is not valid CSV: %v", err)
+	}
+
+	file.NewSheet("Sheet2")
+	file.SetSheetRow("Sheet2", "A1", &[]any{"Name", "Qty"})
+	buffer, err = file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+	res, err = Convert(context.Background(), buffer.Bytes(), Options{Format: "csv"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.CombinedOutput != "" || res.Sheets[1].Output != "Name,Qty\n" {
+		t.Fatalf("expected per-sheet CSV only, got combined %q and %+v", res.CombinedOutput, res.Sheets)
+	}
+
+	_, err = Convert(context.Background(), buffer.Bytes(), Options{Format: "xml"})
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestConvertWithFormatLeavesOutMarkup(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Status", "Site", "Total"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Open", "Docs", 3})
+	file.SetCellFormula("Sheet1", "C2", "1+2")
+	file.SetSheetRow("Sheet1", "A3", &[]any{"Closed", "Home", 4})
+	file.SetRowVisible("Sheet1", 3, false)
+	bold, _ := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	file.SetCellStyle("Sheet1", "A1", "A2", bold)
+	file.SetCellHyperLink("Sheet1", "B2", "https://example.com/docs", "External")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{
+		Format:         FormatCSV,
+		RichText:       true,
+		Hyperlinks:     true,
+		FormulaMode:    FormulaText,
+		HiddenRowsCols: HiddenMark,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sheet := res.Sheets[0]
+	if expected := "Status,Site,Total\nOpen,Docs,=1+2\n" + hiddenMarker + " Closed,Home,4\n"; sheet.Output != expected {
+		t.Fatalf("expected CSV without markup, got:\n%s", sheet.Output)
+	}
+	if !strings.Contains(sheet.Markdown, "| **Open** | [Docs](https://example.com/docs) | `=1+2` |") {
+		t.Fatalf("expected markup in Markdown:\n%s", sheet.Markdown)
+	}
+}
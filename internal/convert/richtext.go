@@ -0,0 +1,168 @@
+package convert
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var monospaceFonts = []string{"mono", "courier", "consolas", "menlo", "monaco", "lucida console", "code"}
+
+// textStyle is the subset of font styling that maps onto inline Markdown.
+type textStyle struct {
+	bold   bool
+	italic bool
+	strike bool
+	mono   bool
+}
+
+func (s textStyle) plain() bool {
+	return !s.bold && !s.italic && !s.strike && !s.mono
+}
+
+func styleFromFont(font *excelize.Font) textStyle {
+	if font == nil {
+		return textStyle{}
+	}
+	family := strings.ToLower(font.Family)
+	mono := false
+	for _, name := range monospaceFonts {
+		if strings.Contains(family, name) {
+			mono = true
+			break
+		}
+	}
+	return textStyle{bold: font.Bold, italic: font.Italic, strike: font.Strike, mono: mono}
+}
+
+// richTextHandler rewrites cell values as inline Markdown using rich-text
+// runs and cell fonts.
+type richTextHandler struct {
+	file      *excelize.File
+	sheetName string
+	styles    map[int]textStyle
+}
+
+func newRichTextHandler(file *excelize.File, sheetName string) *richTextHandler {
+	return &richTextHandler{file: file, sheetName: sheetName, styles: map[int]textStyle{}}
+}
+
+// applyRow rewrites the non-empty cells of row (1-based rowNum) in place.
+func (h *richTextHandler) applyRow(cols []string, rowNum int) {
+	for i, value := range cols {
+		if value == "" {
+			continue
+		}
+		cellRef, err := excelize.CoordinatesToCellName(i+1, rowNum)
+		if err != nil {
+			continue
+		}
+		cols[i] = h.cellMarkdown(cellRef, value)
+	}
+}
+
+func (h *richTextHandler) cellMarkdown(cellRef, value string) string {
+	base := h.cellStyle(cellRef)
+
+	runs, err := h.file.GetCellRichText(h.sheetName, cellRef)
+	if err != nil || !hasRunFonts(runs) || joinRuns(runs) != value {
+		if base.plain() {
+			return value
+		}
+		return decorate(value, base)
+	}
+
+	formatted := false
+	styles := make([]textStyle, len(runs))
+	for i, run := range runs {
+		styles[i] = base
+		if run.Font != nil {
+			styles[i] = styleFromFont(run.Font)
+		}
+		formatted = formatted || !styles[i].plain()
+	}
+	if !formatted {
+		return value
+	}
+
+	var builder strings.Builder
+	for i, run := range runs {
+		builder.WriteString(decorate(run.Text, styles[i]))
+	}
+	return builder.String()
+}
+
+func (h *richTextHandler) cellStyle(cellRef string) textStyle {
+	index, err := h.file.GetCellStyle(h.sheetName, cellRef)
+	if err != nil {
+		return textStyle{}
+	}
+	if style, ok := h.styles[index]; ok {
+		return style
+	}
+	style := textStyle{}
+	if definition, err := h.file.GetStyle(index); err == nil && definition != nil {
+		style = styleFromFont(definition.Font)
+	}
+	h.styles[index] = style
+	return style
+}
+
+func hasRunFonts(runs []excelize.RichTextRun) bool {
+	for _, run := range runs {
+		if run.Font != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func joinRuns(runs []excelize.RichTextRun) string {
+	var builder strings.Builder
+	for _, run := range runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+// decorate wraps text in Markdown emphasis markers. Surrounding whitespace
+// stays outside the markers, which GFM requires for them to take effect.
+func decorate(text string, style textStyle) string {
+	core := strings.TrimFunc(text, unicode.IsSpace)
+	if core == "" {
+		return text
+	}
+	start := strings.Index(text, core)
+	lead, trail := text[:start], text[start+len(core):]
+
+	if style.mono {
+		core = codeSpan(core)
+	} else {
+		core = escapeInline(core)
+	}
+	if style.strike {
+		core = "~~" + core + "~~"
+	}
+	if style.italic {
+		core = "*" + core + "*"
+	}
+	if style.bold {
+		core = "**" + core + "**"
+	}
+	return lead + core + trail
+}
+
+// escapeInline backslash-escapes characters that would otherwise be read as
+// emphasis or code markers. Pipes and newlines are left to escapeCell.
+func escapeInline(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		switch r {
+		case '\\', '*', '_', '~', '`':
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
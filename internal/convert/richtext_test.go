@@ -0,0 +1,61 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestDecorate(t *testing.T) {
+	cases := []struct {
+		text     string
+		style    textStyle
+		expected string
+	}{
+		{"Done ", textStyle{bold: true}, "**Done** "},
+		{"old_value", textStyle{strike: true, italic: true}, "*~~old\\_value~~*"},
+		{"mid", textStyle{bold: true, italic: true}, "***mid***"},
+		{"a|b", textStyle{mono: true}, "`a|b`"},
+		{"2*3", textStyle{}, "2\\*3"},
+		{"  ", textStyle{bold: true}, "  "},
+	}
+	for _, tc := range cases {
+		if got := decorate(tc.text, tc.style); got != tc.expected {
+			t.Fatalf("decorate(%q) = %q, want %q", tc.text, got, tc.expected)
+		}
+	}
+}
+
+func TestConvertRichText(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Status")
+	file.SetCellRichText("Sheet1", "A2", []excelize.RichTextRun{
+		{Text: "Blocked", Font: &excelize.Font{Bold: true}},
+		{Text: " by "},
+		{Text: "old_task", Font: &excelize.Font{Strike: true}},
+	})
+	file.SetCellValue("Sheet1", "A3", "run.sh")
+	code, _ := file.NewStyle(&excelize.Style{Font: &excelize.Font{Family: "Courier New"}})
+	file.SetCellStyle("Sheet1", "A3", "A3", code)
+	file.SetCellValue("Sheet1", "A4", "plain_text")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), Options{RichText: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "| Status |\n| --- |\n| **Blocked** by ~~old\\_task~~ |\n| `run.sh` |\n| plain_text |"
+	if res.Sheets[0].Markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", res.Sheets[0].Markdown)
+	}
+
+	plain, _ := Convert(context.Background(), buffer.Bytes(), Options{})
+	if !strings.Contains(plain.Sheets[0].Markdown, "| Blocked by old_task |") {
+		t.Fatalf("expected plain text without rich text option:\n%s", plain.Sheets[0].Markdown)
+	}
+}
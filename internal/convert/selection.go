@@ -0,0 +1,155 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetSelected reports whether the sheet at 1-based position matches one of
+// the selectors: a sheet name, a glob pattern such as "Q* 2024", or a
+// position. Names are matched case-insensitively. No selectors selects
+// every sheet.
+func sheetSelected(name string, position int, selectors []string) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if n, err := strconv.Atoi(selector); err == nil && n == position {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(selector), lower); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sheetRange returns the area of opts.Ranges that applies to sheetName and
+// whether there is one. A range qualified with the sheet's name, such as
+// "Sales!B3:F20", takes precedence over one without a sheet name. When
+// every range names another sheet, the sheet is deselected, which the last
+// result reports.
+func sheetRange(opts Options, sheetName string) (cellRange, bool, bool) {
+	var area cellRange
+	found, qualified := false, false
+	for _, ref := range opts.Ranges {
+		if strings.TrimSpace(ref) == "" {
+			continue
+		}
+		sheet, r, err := parseRange(ref)
+		switch {
+		case err != nil:
+		case strings.EqualFold(sheet, sheetName) && sheet != "":
+			return r, true, true
+		case sheet == "":
+			area, found = r, true
+		default:
+			qualified = true
+		}
+	}
+	return area, found, found || !qualified
+}
+
+// isSelected reports whether the sheet at 1-based position is converted
+// under opts.Sheets and opts.Ranges.
+func isSelected(opts Options, name string, position int) bool {
+	_, _, inRange := sheetRange(opts, name)
+	return inRange && sheetSelected(name, position, opts.Sheets)
+}
+
+// selectColumns keeps the columns named in include, then drops those named
+// in exclude. Columns are matched by header text without markup,
+// case-insensitively, or by column letter. It returns warnings for included
+// names that were not found, and an error when none of them were.
+func selectColumns(data *sheetData, include, exclude []string) ([]string, error) {
+	if (len(include) == 0 && len(exclude) == 0) || len(data.rows) == 0 {
+		return nil, nil
+	}
+	width := 0
+	for _, row := range data.rows {
+		width = max(width, len(row))
+	}
+
+	headers := data.plainHeader()
+	matches := func(col int, names []string) (int, bool) {
+		header := ""
+		if col < len(headers) {
+			header = strings.TrimSpace(headers[col])
+		}
+		letter, _ := excelize.ColumnNumberToName(data.colNum(col))
+		for i, name := range names {
+			name = strings.TrimSpace(name)
+			if (header != "" && strings.EqualFold(name, header)) || name == letter {
+				return i, true
+			}
+		}
+		return -1, false
+	}
+
+	found := make([]bool, len(include))
+	keep := []int{}
+	for col := 0; col < width; col++ {
+		if len(include) > 0 {
+			i, ok := matches(col, include)
+			if !ok {
+				continue
+			}
+			found[i] = true
+		}
+		if _, ok := matches(col, exclude); ok {
+			continue
+		}
+		keep = append(keep, col)
+	}
+
+	missing := []string{}
+	for i, name := range include {
+		if !found[i] {
+			missing = append(missing, strings.TrimSpace(name))
+		}
+	}
+	if len(include) > 0 && len(missing) == len(include) {
+		return nil, fmt.Errorf("%w: none of the columns %q were found", ErrInvalidOption, missing)
+	}
+
+	keepColumns(data, keep)
+
+	warnings := []string{}
+	for _, name := range missing {
+		warnings = append(warnings, fmt.Sprintf("Column %q was not found.", name))
+	}
+	return warnings, nil
+}
+
+// keepColumns reduces every row to the given column indexes, in order.
+func keepColumns(data *sheetData, keep []int) {
+	colNums := make([]int, len(keep))
+	for i, col := range keep {
+		colNums[i] = data.colNum(col)
+	}
+	for r, row := range data.rows {
+		cells := make([]string, len(keep))
+		for i, col := range keep {
+			if col < len(row) {
+				cells[i] = row[col]
+			}
+		}
+		data.rows[r] = trimTrailingEmpty(cells)
+	}
+	if data.header != nil {
+		header := make([]string, len(keep))
+		for i, col := range keep {
+			if col < len(data.header) {
+				header[i] = data.header[col]
+			}
+		}
+		data.header = header
+	}
+	data.colNums = colNums
+}
@@ -0,0 +1,191 @@
+package convert
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetScan is what a single pass over the worksheet XML finds. Reading it
+// this way avoids excelize loading the whole worksheet into memory, which
+// also makes its row iterator re-encode the sheet.
+type sheetScan struct {
+	formulas   formulaCells
+	frozenRows int
+	// merges are the merged ranges in sheet order.
+	merges []MergeRange
+}
+
+// formulaCells maps 1-based rows to their formula cells, in sheet order.
+type formulaCells map[int][]formulaCell
+
+// formulaCell is a formula cell at a 1-based column. text is empty for the
+// cells a shared formula is copied to; excelize derives theirs.
+type formulaCell struct {
+	col  int
+	text string
+}
+
+// scanSheet reads the formula cells, the frozen rows of the last sheet view
+// and the merged cells. Shared formulas count for every cell they cover. It
+// fails when the worksheet XML is not held in memory, e.g. when excelize
+// unzipped it to a temporary file.
+func scanSheet(file *excelize.File, sheetName string) (sheetScan, error) {
+	part, err := worksheetPath(file, sheetName)
+	if err != nil {
+		return sheetScan{formulas: formulaCells{}}, err
+	}
+	content, ok := file.Pkg.Load(part)
+	if !ok {
+		return sheetScan{formulas: formulaCells{}}, fmt.Errorf("worksheet %s is not loaded", part)
+	}
+	data, _ := content.([]byte)
+	return scanWorksheet(bytes.NewReader(data))
+}
+
+// scanArchivedSheet is scanSheet reading the worksheet XML straight from
+// archive, the zip file was opened from. A sheet excelize unzipped to a
+// temporary file is decoded as it is inflated instead of from memory.
+func scanArchivedSheet(archive *zip.Reader, file *excelize.File, sheetName string) (sheetScan, error) {
+	part, err := worksheetPath(file, sheetName)
+	if err != nil {
+		return sheetScan{formulas: formulaCells{}}, err
+	}
+	content, err := archive.Open(part)
+	if err != nil {
+		return sheetScan{formulas: formulaCells{}}, err
+	}
+	defer content.Close()
+	return scanWorksheet(content)
+}
+
+// scanWorksheet does the work of scanSheet on the worksheet XML in r.
+func scanWorksheet(r io.Reader) (sheetScan, error) {
+	scan := sheetScan{formulas: formulaCells{}}
+	decoder := xml.NewDecoder(r)
+	row, col, inCell, inFormula := 0, 0, false, false
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return scan, nil
+		}
+		if err != nil {
+			return scan, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "row":
+				row++
+				if n, err := strconv.Atoi(xmlAttr(element, "r")); err == nil {
+					row = n
+				}
+				col = 0
+			case "c":
+				col++
+				if c, _, err := excelize.CellNameToCoordinates(xmlAttr(element, "r")); err == nil {
+					col = c
+				}
+				inCell = true
+			case "f":
+				if n := len(scan.formulas[row]); inCell && (n == 0 || scan.formulas[row][n-1].col != col) {
+					scan.formulas[row] = append(scan.formulas[row], formulaCell{col: col})
+					inFormula = true
+				}
+			case "sheetView":
+				scan.frozenRows = 0
+			case "pane":
+				if xmlAttr(element, "state") == "frozen" {
+					split, _ := strconv.ParseFloat(xmlAttr(element, "ySplit"), 64)
+					scan.frozenRows = int(split)
+				}
+			case "mergeCell":
+				if merge, ok := parseMerge(xmlAttr(element, "ref")); ok {
+					scan.merges = append(scan.merges, merge)
+				}
+			}
+		case xml.CharData:
+			if inFormula {
+				cells := scan.formulas[row]
+				cells[len(cells)-1].text += string(element)
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "c":
+				inCell = false
+			case "f":
+				inFormula = false
+			}
+		}
+	}
+}
+
+// worksheetPath resolves the package path of a sheet's XML through
+// xl/workbook.xml and its relationships.
+func worksheetPath(file *excelize.File, sheetName string) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(file, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if err := decodePart(file, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+
+	id := ""
+	for _, sheet := range workbook.Sheets {
+		if !strings.EqualFold(sheet.Name, sheetName) {
+			continue
+		}
+		for _, attr := range sheet.Attrs {
+			if attr.Name.Local == "id" && attr.Name.Space != "" {
+				id = attr.Value
+			}
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if id != "" && rel.ID == id {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", fmt.Errorf("worksheet %q not found", sheetName)
+}
+
+// decodePart unmarshals a package part held in memory.
+func decodePart(file *excelize.File, name string, v any) error {
+	content, ok := file.Pkg.Load(name)
+	if !ok {
+		return fmt.Errorf("%s not found", name)
+	}
+	data, _ := content.([]byte)
+	return xml.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,316 @@
+package convert
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// streamXMLSizeLimit is the largest worksheet and shared string size
+// ConvertTo keeps in memory; larger ones are unzipped to temporary files.
+const streamXMLSizeLimit = 4 << 20
+
+// ConvertTo reads the XLSX workbook in r and writes the combined Markdown
+// document to w one table row at a time. When r is an *os.File it is opened
+// by name. Either way excelize reads the compressed archive into memory
+// while opening it; worksheet XML larger than 4 MB is then unzipped to
+// temporary files in Options.TempDir and read back row by row, so memory
+// use grows with the size of the upload rather than with the sheets'
+// cells. The returned Result carries the sheet counts, warnings, errors and
+// skipped sheets but no Markdown.
+//
+// Only options that apply row by row are supported: sheet and range
+// selection, value mode, explicit column alignments and the limits. Merged
+// cells are flattened and formulas output as stored values, with the same
+// warnings as Convert. Other options are rejected with ErrInvalidOption.
+func ConvertTo(ctx context.Context, r io.ReaderAt, size int64, w io.Writer, opts Options) (Result, error) {
+	result := Result{
+		Sheets:  []SheetResult{},
+		Skipped: []SkippedSheet{},
+		Meta: Meta{
+			GeneratedAt: time.Now().UTC(),
+		},
+	}
+
+	if err := checkCtx(ctx); err != nil {
+		return result, err
+	}
+	if err := opts.Validate(); err != nil {
+		return result, err
+	}
+	if err := checkStreamable(opts); err != nil {
+		return result, err
+	}
+	openOpts, _ := openOptions(opts)
+	if openOpts.UnzipXMLSizeLimit == 0 || openOpts.UnzipXMLSizeLimit > streamXMLSizeLimit {
+		openOpts.UnzipXMLSizeLimit = streamXMLSizeLimit
+	}
+	if openOpts.UnzipSizeLimit > 0 {
+		openOpts.UnzipXMLSizeLimit = min(openOpts.UnzipXMLSizeLimit, openOpts.UnzipSizeLimit)
+	}
+
+	var file *excelize.File
+	var err error
+	if spooled, ok := r.(*os.File); ok {
+		file, err = excelize.OpenFile(spooled.Name(), openOpts)
+	} else {
+		file, err = excelize.OpenReader(io.NewSectionReader(r, 0, size), openOpts)
+	}
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+	defer file.Close()
+	// The merge and formula warnings come from the worksheet XML, read
+	// from the archive since large sheets are not kept in memory. An
+	// encrypted workbook is no zip; its sheets are scanned from memory.
+	archive, _ := zip.NewReader(r, size)
+
+	sheets := file.GetSheetList()
+	result.Meta.SheetCount = len(sheets)
+	selected := 0
+	for index, sheetName := range sheets {
+		if isSelected(opts, sheetName, index+1) {
+			selected++
+		}
+	}
+	if opts.MaxSheets > 0 && selected > opts.MaxSheets {
+		return result, ErrTooManySheets
+	}
+
+	for index, sheetName := range sheets {
+		if err := checkCtx(ctx); err != nil {
+			return result, err
+		}
+		if !isSelected(opts, sheetName, index+1) {
+			skipSheet(&result, opts, index, sheetName, "not selected")
+			continue
+		}
+		hidden, hiddenErr := isHiddenSheet(file, sheetName)
+		if hiddenErr == nil && hidden && !opts.IncludeHiddenSheets {
+			skipSheet(&result, opts, index, sheetName, "hidden sheet")
+			continue
+		}
+
+		sheetResult := SheetResult{Name: sheetName}
+		scanStreamedSheet(archive, file, &sheetResult, opts)
+		if hiddenErr != nil {
+			sheetResult.Warnings = append(sheetResult.Warnings, "Sheet visibility could not be determined; processed as visible.")
+		}
+		if len(result.Sheets) > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return result, err
+			}
+		}
+		opts.report(ProgressEvent{Type: ProgressStarted, Sheet: sheetName, Index: index + 1, Total: len(sheets)})
+		if err := streamSheet(ctx, file, &sheetResult, w, opts); err != nil {
+			return result, err
+		}
+		result.Sheets = append(result.Sheets, sheetResult)
+		reportSheet(opts, sheetResult, index, len(sheets))
+	}
+
+	result.Meta.Processed = len(result.Sheets)
+	result.Meta.SkippedCount = len(result.Skipped)
+	return result, nil
+}
+
+// checkStreamable rejects the options that need a whole sheet in memory.
+func checkStreamable(opts Options) error {
+	renderer, _ := selectRenderer(opts)
+	header, _ := parseHeaderMode(opts.HeaderMode)
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{"format", renderer != nil},
+		{"align", opts.AlignColumns},
+		{"formula mode", opts.FormulaMode != "" && opts.FormulaMode != FormulaCached},
+		{"merge strategy", opts.MergeStrategy != "" && opts.MergeStrategy != MergeTopLeft},
+		{"rich text", opts.RichText},
+		{"hyperlinks", opts.Hyperlinks},
+		{"comments", opts.Comments != "" && opts.Comments != CommentNone},
+		{"header mode", header.mode != HeaderFirstRow},
+		{"table detection", opts.DetectTables},
+		{"named objects", opts.NamedObjects},
+		{"column selection", len(opts.Columns) > 0 || len(opts.ExcludeColumns) > 0},
+		{"hidden rows and columns mode", opts.HiddenRowsCols != "" && opts.HiddenRowsCols != HiddenInclude},
+		{"outline mode", opts.Outline != "" && opts.Outline != OutlineNone},
+		{"filter", strings.TrimSpace(opts.Filter) != ""},
+		{"sort", strings.TrimSpace(opts.Sort) != ""},
+	}
+	for _, option := range unsupported {
+		if option.set {
+			return fmt.Errorf("%w: %s is not supported when streaming", ErrInvalidOption, option.name)
+		}
+	}
+	return nil
+}
+
+// scanStreamedSheet adds the merge and formula warnings and the formula
+// cells within the selected range to sheetResult.
+func scanStreamedSheet(archive *zip.Reader, file *excelize.File, sheetResult *SheetResult, opts Options) {
+	var scan sheetScan
+	var err error
+	if archive != nil {
+		scan, err = scanArchivedSheet(archive, file, sheetResult.Name)
+	} else {
+		scan, err = scanSheet(file, sheetResult.Name)
+	}
+	if err != nil {
+		sheetResult.Warnings = append(sheetResult.Warnings, "Merged cells and formulas could not be read; they were output as stored.")
+		return
+	}
+
+	// The top-left strategy leaves the rows alone, so there is no data.
+	if warning := applyMerges(&sheetData{}, scan.merges, opts.MergeStrategy); warning != "" {
+		sheetResult.Warnings = append(sheetResult.Warnings, warning)
+	}
+	area, _, _ := sheetRange(opts, sheetResult.Name)
+	formulas := newFormulaHandler(file, sheetResult.Name, opts.FormulaMode, area, scan.formulas)
+	rows := make([]int, 0, len(scan.formulas))
+	for row := range scan.formulas {
+		rows = append(rows, row)
+	}
+	slices.Sort(rows)
+	for _, row := range rows {
+		formulas.applyRow(nil, row)
+	}
+	sheetResult.Warnings = append(sheetResult.Warnings, formulas.warnings()...)
+	sheetResult.FormulaCount = len(formulas.refs)
+	sheetResult.FormulaCells = formulas.refs
+}
+
+// streamSheet writes the "## Name" section of one sheet to w. The rows are
+// read twice: once to size the table and enforce the cell limit, then to
+// write it. Sheet errors are written to the section and recorded in
+// sheetResult; only write errors and context expiry are returned.
+func streamSheet(ctx context.Context, file *excelize.File, sheetResult *SheetResult, w io.Writer, opts Options) error {
+	sw := &sectionWriter{w: w}
+	sw.line("## " + sheetResult.Name)
+
+	area, restricted, _ := sheetRange(opts, sheetResult.Name)
+	width, length, cellCount := 0, 0, 0
+	err := streamRows(ctx, file, sheetResult.Name, area, restricted, func(row []string) error {
+		sheetResult.RowCount++
+		cellCount += len(row)
+		if opts.MaxCellsPerSheet > 0 && cellCount > opts.MaxCellsPerSheet {
+			return ErrSheetTooLarge
+		}
+		if len(row) > 0 {
+			width = max(width, len(row))
+			length = sheetResult.RowCount
+		}
+		return nil
+	})
+	sheetResult.ColCount = width
+	if err != nil {
+		if ctxErr := checkCtx(ctx); ctxErr != nil {
+			return ctxErr
+		}
+		sheetResult.Error = err.Error()
+		sheetResult.Err = err
+		sw.line("> Error: " + sheetResult.Error)
+		return sw.err
+	}
+
+	for _, warning := range sheetResult.Warnings {
+		sw.line("> Warning: " + warning)
+	}
+	if width == 0 {
+		sw.line(emptySheetMessage)
+		return sw.err
+	}
+
+	written := 0
+	err = streamRows(ctx, file, sheetResult.Name, area, restricted, func(row []string) error {
+		if written == length {
+			return errStopRows
+		}
+		padded := make([]string, width)
+		copy(padded, row)
+		sw.line(formatRow(padded))
+		if written == 0 {
+			aligns := columnAlignments([][]string{padded}, false, opts.ColumnAlignments, nil, func(col int) int {
+				return max(area.firstCol, 1) + col
+			})
+			sw.line(formatSeparator(width, aligns))
+		}
+		written++
+		return sw.err
+	})
+	if err != nil && !errors.Is(err, errStopRows) {
+		if ctxErr := checkCtx(ctx); ctxErr != nil {
+			return ctxErr
+		}
+		if sw.err != nil {
+			return sw.err
+		}
+		// The table is already partly written, so the error follows it.
+		sheetResult.Error = err.Error()
+		sheetResult.Err = err
+		sw.line("> Error: " + sheetResult.Error)
+	}
+	return sw.err
+}
+
+// errStopRows ends streamRows early without an error.
+var errStopRows = errors.New("stop reading rows")
+
+// streamRows calls fn with every row of the sheet within area, trimmed of
+// trailing empty cells. Rows above the area are skipped rather than passed
+// on empty.
+func streamRows(ctx context.Context, file *excelize.File, sheetName string, area cellRange, restricted bool, fn func(row []string) error) error {
+	rows, err := file.Rows(sheetName)
+	if err != nil {
+		return fmt.Errorf("unable to read sheet: %w", err)
+	}
+	defer rows.Close()
+
+	for rowNum := 1; rows.Next(); rowNum++ {
+		if err := checkCtx(ctx); err != nil {
+			return err
+		}
+		if restricted && area.lastRow > 0 && rowNum > area.lastRow {
+			break
+		}
+		cols, err := rows.Columns()
+		if err != nil {
+			return fmt.Errorf("failed to read row: %w", err)
+		}
+		if restricted {
+			if rowNum < area.firstRow {
+				continue
+			}
+			cols = area.clipRow(cols, rowNum)
+			cols = cols[min(area.firstCol-1, len(cols)):]
+		}
+		if err := fn(trimTrailingEmpty(cols)); err != nil {
+			return err
+		}
+	}
+	if err := rows.Error(); err != nil {
+		return fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return nil
+}
+
+// sectionWriter writes lines to w and keeps the first write error, after
+// which it writes nothing.
+type sectionWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *sectionWriter) line(text string) {
+	if sw.err == nil {
+		_, sw.err = io.WriteString(sw.w, text+"\n")
+	}
+}
@@ -0,0 +1,103 @@
+package convert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestConvertToMatchesConvert(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Name", "Amount", "Note"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Desk", 100, "a | b"})
+	file.SetSheetRow("Sheet1", "A4", &[]any{"Lamp", 20})
+	file.MergeCell("Sheet1", "A4", "A5")
+	file.SetCellFormula("Sheet1", "B5", "SUM(B2:B4)")
+	file.NewSheet("Empty")
+	file.NewSheet("Ranges")
+	file.SetCellValue("Ranges", "A1", "title")
+	file.SetSheetRow("Ranges", "B3", &[]any{"Region", "Sales", "Extra"})
+	file.SetSheetRow("Ranges", "B4", &[]any{"North", 10, "x"})
+	file.SetCellValue("Ranges", "B8", "outside")
+	file.NewSheet("Hidden")
+	file.SetSheetVisible("Hidden", false)
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+	input := buffer.Bytes()
+
+	for _, opts := range []Options{
+		{MaxSheets: 10},
+		{Ranges: []string{"B3:C6"}, ColumnAlignments: map[string]Alignment{"Sales": AlignRight}},
+		{Ranges: []string{"B3:D6"}, ColumnAlignments: map[string]Alignment{"C": AlignCenter}},
+	} {
+		expected, err := Convert(context.Background(), input, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var out bytes.Buffer
+		res, err := ConvertTo(context.Background(), bytes.NewReader(input), int64(len(input)), &out, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != expected.CombinedMarkdown {
+			t.Fatalf("streamed markdown differs:\n%s\nexpected:\n%s", out.String(), expected.CombinedMarkdown)
+		}
+		if len(res.Sheets) != len(expected.Sheets) || len(res.Skipped) != len(expected.Skipped) {
+			t.Fatalf("unexpected sheets: %+v skipped: %+v", res.Sheets, res.Skipped)
+		}
+		for i, sheet := range res.Sheets {
+			if sheet.RowCount != expected.Sheets[i].RowCount || sheet.ColCount != expected.Sheets[i].ColCount {
+				t.Fatalf("sheet %q: got %dx%d, expected %dx%d", sheet.Name, sheet.RowCount, sheet.ColCount, expected.Sheets[i].RowCount, expected.Sheets[i].ColCount)
+			}
+			if !slices.Equal(sheet.Warnings, expected.Sheets[i].Warnings) || !slices.Equal(sheet.FormulaCells, expected.Sheets[i].FormulaCells) {
+				t.Fatalf("sheet %q: got warnings %q and formulas %q, expected %q and %q", sheet.Name, sheet.Warnings, sheet.FormulaCells, expected.Sheets[i].Warnings, expected.Sheets[i].FormulaCells)
+			}
+		}
+	}
+	var aligned bytes.Buffer
+	if _, err := ConvertTo(context.Background(), bytes.NewReader(input), int64(len(input)), &aligned, Options{Ranges: []string{"B3:D6"}, ColumnAlignments: map[string]Alignment{"C": AlignCenter}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(aligned.String(), "| Region | Sales | Extra |\n| --- | :---: | --- |") {
+		t.Fatalf("expected sheet column C centered, got:\n%s", aligned.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(path, input, 0o644); err != nil {
+		t.Fatalf("failed to write workbook: %v", err)
+	}
+	spooled, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer spooled.Close()
+	var fromFile bytes.Buffer
+	if _, err := ConvertTo(context.Background(), spooled, int64(len(input)), &fromFile, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(fromFile.String(), "> Warning: Merged cells were flattened to their top-left value: A4:A5.\n> Warning: Formulas were detected") {
+		t.Fatalf("expected merge and formula warnings, got:\n%s", fromFile.String())
+	}
+
+	var out bytes.Buffer
+	res, err := ConvertTo(context.Background(), bytes.NewReader(input), int64(len(input)), &out, Options{MaxCellsPerSheet: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(res.Sheets[0].Err, ErrSheetTooLarge) {
+		t.Fatalf("expected a sheet limit error, got %+v", res.Sheets[0])
+	}
+
+	if _, err := ConvertTo(context.Background(), bytes.NewReader(input), int64(len(input)), &out, Options{Filter: "Amount > 1"}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected invalid option error, got %v", err)
+	}
+}
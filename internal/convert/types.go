@@ -2,11 +2,164 @@ package convert
 
 import "time"
 
+// ValueMode selects how cell values are read.
+type ValueMode string
+
+const (
+	// ValuesFormatted renders cells with their number format: dates,
+	// percentages, thousands separators, currency symbols, fixed decimals
+	// and custom format codes. Locale-dependent date formats use ISO 8601.
+	ValuesFormatted ValueMode = "formatted"
+	// ValuesRaw outputs the stored value, e.g. 0.153 or the date serial 45123.
+	ValuesRaw ValueMode = "raw"
+)
+
+// FormulaMode selects how formula cells are output.
+type FormulaMode string
+
+const (
+	// FormulaCached outputs the value stored in the workbook (default).
+	FormulaCached FormulaMode = "cached"
+	// FormulaText outputs the formula in a code span, e.g. `=SUM(A1:A3)`.
+	FormulaText FormulaMode = "formula"
+	// FormulaBoth outputs the stored value with the formula in a footnote.
+	FormulaBoth FormulaMode = "both"
+	// FormulaRecalculate calculates formulas that have no stored value,
+	// e.g. in workbooks written by scripts.
+	FormulaRecalculate FormulaMode = "recalculate"
+)
+
+// MergeStrategy selects how merged cell ranges are output.
+type MergeStrategy string
+
+const (
+	// MergeTopLeft keeps the value in the top-left cell and leaves the rest
+	// of the range empty (default).
+	MergeTopLeft MergeStrategy = "top-left"
+	// MergeFill repeats the top-left value across the merged range.
+	MergeFill MergeStrategy = "fill"
+	// MergeHTML renders sheets with merges as HTML tables using colspan and
+	// rowspan, which GFM tables cannot express.
+	MergeHTML MergeStrategy = "html"
+)
+
+// CommentMode selects how cell comments and notes are output.
+type CommentMode string
+
+const (
+	// CommentNone drops comments (default).
+	CommentNone CommentMode = "none"
+	// CommentFootnotes attaches comments to their cell as footnotes.
+	CommentFootnotes CommentMode = "footnotes"
+	// CommentNotes lists comments in a "Notes" section under the sheet.
+	CommentNotes CommentMode = "notes"
+)
+
 // Options controls conversion behavior and limits.
 type Options struct {
 	IncludeHiddenSheets bool
 	MaxSheets           int
 	MaxCellsPerSheet    int
+	// Concurrency is the number of sheets Convert converts in parallel.
+	// Zero or one converts them one after another. Result.Sheets keeps
+	// workbook order either way. A custom Renderer must then be safe for
+	// concurrent use. ConvertTo always streams one sheet at a time.
+	Concurrency int
+	// Progress, when set, is called as each sheet starts, finishes, fails
+	// or is skipped. With Concurrency it is called from several goroutines
+	// at once.
+	Progress func(ProgressEvent)
+	// TempDir is where worksheets over UnzipXMLSizeLimit are unzipped;
+	// empty uses the system temporary directory.
+	TempDir string
+	// UnzipSizeLimit caps the bytes a workbook may inflate to when opened.
+	// UnzipXMLSizeLimit is the worksheet and shared string size above which
+	// parts are unzipped to temporary files instead of memory; it is lowered
+	// to UnzipSizeLimit when larger. Zero keeps the excelize defaults of
+	// 16 GB and 16 MB.
+	UnzipSizeLimit    int64
+	UnzipXMLSizeLimit int64
+
+	// HiddenRowsCols selects how hidden rows and columns of visible sheets
+	// are output.
+	HiddenRowsCols HiddenMode
+
+	// Format selects a built-in renderer (see Formats). Markdown is always
+	// produced; other formats are added to SheetResult.Output and
+	// Result.CombinedOutput. CSV and TSV hold one table, so their combined
+	// output is empty unless exactly one sheet of one table is converted.
+	Format string
+	// Renderer overrides Format with a custom renderer.
+	Renderer Renderer
+
+	// AlignColumns detects column types and right-aligns numeric columns
+	// and left-aligns text columns in the Markdown separator row.
+	AlignColumns bool
+	// ColumnAlignments forces alignment per column, keyed by header text or
+	// column letter. It applies with or without AlignColumns.
+	ColumnAlignments map[string]Alignment
+
+	// Values selects formatted (default) or raw cell values.
+	Values ValueMode
+	// FormulaMode selects how formula cells are output.
+	FormulaMode FormulaMode
+	// MergeStrategy selects how merged cell ranges are output.
+	MergeStrategy MergeStrategy
+	// RichText maps bold, italic, strikethrough and monospace fonts (from
+	// rich-text runs or the cell style) to **, *, ~~ and backticks.
+	RichText bool
+	// Hyperlinks outputs linked cells as [text](url). Links to other sheets
+	// point at the sheet's heading anchor, e.g. #sheet-name.
+	Hyperlinks bool
+	// Comments selects how cell comments are output. Only Markdown output
+	// has them; other formats fail with ErrInvalidOption.
+	Comments CommentMode
+	// HeaderMode selects which rows become the table header.
+	HeaderMode HeaderMode
+	// DetectTables splits sheets holding several tables separated by blank
+	// rows or columns into one table per block, listed in
+	// SheetResult.Tables.
+	DetectTables bool
+	// NamedObjects converts only the Excel Tables and defined names of each
+	// sheet, one table per object named after it. Table headers come from
+	// the table definition. Sheets without any are skipped. It takes
+	// precedence over DetectTables.
+	NamedObjects bool
+
+	// Sheets selects the sheets to convert by name, glob pattern (e.g.
+	// "Q? 2024") or 1-based position. Names match case-insensitively.
+	// Other sheets are skipped and do not count against MaxSheets.
+	Sheets []string
+	// Ranges restrict conversion to A1 areas such as "B3:F20", "A:D" or
+	// "5:100". A sheet-qualified range such as "Sales!B3:F20" applies to
+	// its own sheet and one without a sheet name to every other sheet; when
+	// all ranges are qualified, sheets without one are not selected. At
+	// most one range may name each sheet. Cells outside the range do not
+	// count against MaxCellsPerSheet.
+	Ranges []string
+	// Columns keeps only the named columns and ExcludeColumns drops the
+	// named ones. Columns match by header text, case-insensitively, or by
+	// column letter. An include list matching no column of a sheet fails
+	// that sheet with ErrInvalidOption in SheetResult.Err; the conversion
+	// fails with it only when no converted sheet has a matching column.
+	Columns        []string
+	ExcludeColumns []string
+
+	// Outline selects how row outline levels (grouped rows) are output.
+	Outline OutlineMode
+
+	// Filter keeps the data rows matching an expression such as
+	// `Status = "Open" and Priority <= 2`, referring to columns by header
+	// name. Comparisons use =, !=, <, <=, >, >= or contains and combine
+	// with and, or, not and parentheses; [Due Date] names a column with
+	// spaces. A filter naming a column that a converted table lacks fails
+	// its sheet with ErrInvalidOption in SheetResult.Err rather than
+	// returning the rows unfiltered; the conversion fails with it only when
+	// every converted sheet lacks a filter column.
+	Filter string
+	// Sort orders data rows by comma-separated header names, prefixed with
+	// - for descending order, e.g. "-Updated, Priority".
+	Sort string
 }
 
 // Result is the top-level conversion response.
@@ -14,6 +167,8 @@ type Result struct {
 	Sheets           []SheetResult  `json:"sheets"`
 	Skipped          []SkippedSheet `json:"skipped,omitempty"`
 	CombinedMarkdown string         `json:"combined_markdown"`
+	Format           string         `json:"format,omitempty"`
+	CombinedOutput   string         `json:"combined_output,omitempty"`
 	Meta             Meta           `json:"meta"`
 }
 
@@ -29,10 +184,67 @@ type Meta struct {
 type SheetResult struct {
 	Name     string   `json:"name"`
 	Markdown string   `json:"markdown,omitempty"`
+	Output   string   `json:"output,omitempty"`
 	Warnings []string `json:"warnings,omitempty"`
 	Error    string   `json:"error,omitempty"`
 	RowCount int      `json:"row_count"`
 	ColCount int      `json:"col_count"`
+	// FormulaCount and FormulaCells report the formula cells within the
+	// converted range, e.g. "C4".
+	FormulaCount int      `json:"formula_count,omitempty"`
+	FormulaCells []string `json:"formula_cells,omitempty"`
+	// Tables is set when Options.DetectTables or Options.NamedObjects is
+	// enabled.
+	Tables []TableResult `json:"tables,omitempty"`
+
+	// Err is the underlying sheet error, kept for errors.Is checks.
+	Err error `json:"-"`
+}
+
+// TableResult is one table found within a sheet. Name is set for Excel
+// Tables and defined names.
+type TableResult struct {
+	Name     string `json:"name,omitempty"`
+	Range    string `json:"range"`
+	Markdown string `json:"markdown"`
+	Output   string `json:"output,omitempty"`
+	RowCount int    `json:"row_count"`
+	ColCount int    `json:"col_count"`
+	// TotalsRow reports that the last row is the table's totals row.
+	TotalsRow bool `json:"totals_row,omitempty"`
+}
+
+// title is the heading of the table: its name, or its range.
+func (t TableResult) title() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Range
+}
+
+// ProgressType is the kind of a ProgressEvent.
+type ProgressType string
+
+const (
+	ProgressStarted  ProgressType = "started"
+	ProgressFinished ProgressType = "finished"
+	ProgressSkipped  ProgressType = "skipped"
+	ProgressError    ProgressType = "error"
+)
+
+// ProgressEvent reports a change in the state of one sheet. Index is the
+// 1-based position of the sheet and Total the number of sheets in the
+// workbook. Rows and Warnings are set when the sheet finishes, Reason when
+// it is skipped and Error when it fails.
+type ProgressEvent struct {
+	Type     ProgressType `json:"type"`
+	Sheet    string       `json:"sheet"`
+	Index    int          `json:"index"`
+	Total    int          `json:"total"`
+	Rows     int          `json:"rows,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+	Reason   string       `json:"reason,omitempty"`
+	Error    string       `json:"error,omitempty"`
 }
 
 // SkippedSheet captures sheets that were intentionally skipped.
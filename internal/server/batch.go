@@ -0,0 +1,167 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"excellent-md/internal/config"
+	"excellent-md/internal/storage"
+	"excellent-md/pkg/xlsxmd"
+)
+
+// batchResponse answers an upload of several workbooks or a ZIP archive.
+type batchResponse struct {
+	OK               bool             `json:"ok"`
+	Workbooks        []workbookResult `json:"workbooks"`
+	CombinedMarkdown string           `json:"combined_markdown"`
+}
+
+// workbookResult is the conversion of one workbook of a batch. Result is
+// nil when the workbook could not be converted.
+type workbookResult struct {
+	Filename string `json:"filename"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	*xlsxmd.Result
+}
+
+// convertBatch converts each upload in turn, each bounded by the conversion
+// timeout and all of them by ctx, and joins their Markdown under a heading
+// per workbook. A workbook that fails is reported without failing the
+// batch.
+func convertBatch(ctx context.Context, cfg config.Config, store storage.Store, uploads []upload) batchResponse {
+	resp := batchResponse{OK: true, Workbooks: []workbookResult{}}
+	blocks := []string{}
+	for _, in := range uploads {
+		workbookCtx, cancel := context.WithTimeout(ctx, cfg.ConversionTimeout)
+		result, err := convertUpload(workbookCtx, store, in, time.Now())
+		cancel()
+
+		blocks = append(blocks, "# "+in.filename, "")
+		if err != nil {
+			resp.Workbooks = append(resp.Workbooks, workbookResult{Filename: in.filename, Error: err.Error()})
+			blocks = append(blocks, "> Error: "+err.Error(), "")
+			continue
+		}
+		resp.Workbooks = append(resp.Workbooks, workbookResult{Filename: in.filename, OK: true, Result: &result})
+		blocks = append(blocks, result.CombinedMarkdown)
+	}
+	resp.CombinedMarkdown = strings.Join(blocks, "\n")
+	return resp
+}
+
+var errZipTooLarge = errors.New("archive expands past the size limit")
+
+// readZip extracts the .xlsx workbooks of a ZIP archive; other files are
+// ignored. Archives with too many entries, nested archives, or contents
+// inflating past budget, what is left of the request's limit, are rejected.
+// The budget is checked against the bytes actually inflated, not the sizes
+// the archive claims. It returns the workbooks and the bytes extracted.
+func readZip(cfg config.Config, name string, payload []byte, options []xlsxmd.Option, budget int64) ([]upload, int64, error) {
+	archive, err := zip.NewReader(bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s is not a valid ZIP archive.", name)
+	}
+	if len(archive.File) > cfg.MaxZipEntries {
+		return nil, 0, fmt.Errorf("%s has too many entries. Archives may hold at most %d.", name, cfg.MaxZipEntries)
+	}
+
+	uploads := []upload{}
+	remaining := budget
+	for _, entry := range archive.File {
+		base := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(entry.Name, "__MACOSX/") {
+			continue
+		}
+		switch strings.ToLower(path.Ext(base)) {
+		case ".zip":
+			return nil, 0, fmt.Errorf("%s contains a nested archive (%s). Nested archives are not supported.", name, entry.Name)
+		case ".xlsx":
+		default:
+			continue
+		}
+
+		data, err := readZipEntry(entry, remaining)
+		if errors.Is(err, errZipTooLarge) {
+			return nil, 0, fmt.Errorf("%s expands past the %d MB limit.", name, cfg.MaxZipBytes>>20)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s could not be extracted: %s is damaged.", name, entry.Name)
+		}
+		remaining -= int64(len(data))
+		uploads = append(uploads, upload{filename: entry.Name, payload: data, options: options})
+	}
+	if len(uploads) == 0 {
+		return nil, 0, fmt.Errorf("%s contains no .xlsx files.", name)
+	}
+	return uploads, budget - remaining, nil
+}
+
+// batchXMLSizeLimit is the worksheet size above which an uploaded workbook
+// is unzipped to temporary files, the excelize default.
+const batchXMLSizeLimit = 16 << 20
+
+// limitInflation bounds how far each workbook may inflate when it is opened
+// by what is left of budget, in upload order. A workbook takes the size
+// its archive declares for its entries from the budget; excelize checks
+// the same sum against the limit, and archive/zip fails reads past the
+// declared sizes. A workbook declaring more than is left fails on its own
+// and takes nothing.
+func limitInflation(uploads []upload, budget int64) {
+	for i := range uploads {
+		// At least one byte is passed, as zero would mean no limit.
+		limit := max(budget, 1)
+		uploads[i].options = append(slices.Clip(uploads[i].options), xlsxmd.WithUnzipLimits(limit, min(limit, batchXMLSizeLimit)))
+		if size := inflatedSize(uploads[i].payload); size <= budget {
+			budget -= size
+		}
+	}
+}
+
+// inflatedSize returns the total size the workbook archive in payload
+// declares for its entries, or 0 when it is not a ZIP archive (an
+// encrypted workbook, or no workbook at all).
+func inflatedSize(payload []byte) int64 {
+	archive, err := zip.NewReader(bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return 0
+	}
+	var size uint64
+	for _, entry := range archive.File {
+		if entry.UncompressedSize64 > math.MaxInt64-size {
+			return math.MaxInt64
+		}
+		size += entry.UncompressedSize64
+	}
+	return int64(size)
+}
+
+// readZipEntry inflates entry, reading at most limit bytes.
+func readZipEntry(entry *zip.File, limit int64) ([]byte, error) {
+	if entry.UncompressedSize64 > uint64(limit) {
+		return nil, errZipTooLarge
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errZipTooLarge
+	}
+	return data, nil
+}
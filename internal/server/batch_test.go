@@ -0,0 +1,205 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"excellent-md/internal/config"
+)
+
+// zipArchive returns a ZIP archive holding files.
+func zipArchive(t *testing.T, files ...testFile) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range files {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			t.Fatalf("failed to build zip: %v", err)
+		}
+		entry.Write(file.data)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to build zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// convertBatchRequest posts files to /api/convert and decodes the batch
+// response; a rejected upload returns its status and error message.
+func convertBatchRequest(t *testing.T, cfg config.Config, files ...testFile) (int, batchResponse, string) {
+	t.Helper()
+	w := serve(convertHandler(cfg, nil), uploadRequest(t, "/api/convert", files...))
+	if w.Code != http.StatusOK {
+		var failure apiError
+		if err := json.Unmarshal(w.Body.Bytes(), &failure); err != nil {
+			t.Fatalf("invalid error response %d: %s", w.Code, w.Body.String())
+		}
+		return w.Code, batchResponse{}, failure.Error
+	}
+	var resp batchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid batch response: %v", err)
+	}
+	return w.Code, resp, ""
+}
+
+func TestConvertBatch(t *testing.T) {
+	sales := buildWorkbook(t, "Sales")
+	costs := buildWorkbook(t, "Costs")
+	broken := []byte("not a workbook")
+
+	tests := []struct {
+		name  string
+		files []testFile
+		// workbooks lists the filename of each result, prefixed with "!"
+		// when that workbook is expected to fail.
+		workbooks []string
+	}{
+		{"several files", []testFile{{"sales.xlsx", sales}, {"costs.xlsx", costs}}, []string{"sales.xlsx", "costs.xlsx"}},
+		{"failed workbook", []testFile{{"sales.xlsx", sales}, {"broken.xlsx", broken}, {"costs.xlsx", costs}}, []string{"sales.xlsx", "!broken.xlsx", "costs.xlsx"}},
+		{"archive", []testFile{{"books.zip", zipArchive(t,
+			testFile{"q1/sales.xlsx", sales},
+			testFile{"readme.txt", []byte("ignored")},
+			testFile{"__MACOSX/q1/._sales.xlsx", []byte("ignored")},
+			testFile{".hidden.xlsx", []byte("ignored")},
+			testFile{"costs.XLSX", costs},
+		)}}, []string{"q1/sales.xlsx", "costs.XLSX"}},
+		{"archive and file", []testFile{{"books.zip", zipArchive(t, testFile{"sales.xlsx", sales})}, {"costs.xlsx", costs}}, []string{"sales.xlsx", "costs.xlsx"}},
+		{"single archive", []testFile{{"books.zip", zipArchive(t, testFile{"sales.xlsx", sales})}}, []string{"sales.xlsx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp, message := convertBatchRequest(t, testConfig(), tt.files...)
+			if code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", code, message)
+			}
+			if !resp.OK || len(resp.Workbooks) != len(tt.workbooks) {
+				t.Fatalf("unexpected workbooks: %+v", resp.Workbooks)
+			}
+			for i, expected := range tt.workbooks {
+				workbook := resp.Workbooks[i]
+				failed := strings.HasPrefix(expected, "!")
+				if workbook.Filename != strings.TrimPrefix(expected, "!") || workbook.OK == failed || (workbook.Error != "") != failed || (workbook.Result == nil) != failed {
+					t.Fatalf("unexpected workbook %d: %+v", i, workbook)
+				}
+				if !strings.Contains(resp.CombinedMarkdown, "# "+workbook.Filename+"\n") {
+					t.Fatalf("expected a heading for %s in:\n%s", workbook.Filename, resp.CombinedMarkdown)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertBatchLimits(t *testing.T) {
+	sales := buildWorkbook(t, "Sales")
+
+	tests := []struct {
+		name    string
+		cfg     func(*config.Config)
+		files   []testFile
+		message string
+	}{
+		{"too many files", func(cfg *config.Config) { cfg.MaxBatchFiles = 2 },
+			[]testFile{{"a.xlsx", sales}, {"b.xlsx", sales}, {"c.xlsx", sales}}, "Too many workbooks"},
+		{"too many workbooks in an archive", func(cfg *config.Config) { cfg.MaxBatchFiles = 1 },
+			[]testFile{{"books.zip", zipArchive(t, testFile{"a.xlsx", sales}, testFile{"b.xlsx", sales})}}, "Too many workbooks"},
+		{"too many entries", func(cfg *config.Config) { cfg.MaxZipEntries = 2 },
+			[]testFile{{"books.zip", zipArchive(t, testFile{"a.xlsx", sales}, testFile{"b.txt", nil}, testFile{"c.txt", nil})}}, "too many entries"},
+		{"too large", func(cfg *config.Config) { cfg.MaxZipBytes = int64(len(sales)) * 3 / 2 },
+			[]testFile{{"books.zip", zipArchive(t, testFile{"a.xlsx", sales}, testFile{"b.xlsx", sales})}}, "expands past"},
+		{"nested archive", nil,
+			[]testFile{{"books.zip", zipArchive(t, testFile{"a.xlsx", sales}, testFile{"more/inner.zip", zipArchive(t, testFile{"b.xlsx", sales})})}}, "nested archive"},
+		{"no workbooks", nil,
+			[]testFile{{"books.zip", zipArchive(t, testFile{"notes.txt", []byte("hi")})}}, "contains no .xlsx files"},
+		{"not an archive", nil,
+			[]testFile{{"books.zip", []byte("not a zip")}}, "not a valid ZIP archive"},
+		{"other file type", nil,
+			[]testFile{{"a.xlsx", sales}, {"b.csv", []byte("a,b")}}, "Only .xlsx files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+			code, _, message := convertBatchRequest(t, cfg, tt.files...)
+			if code != http.StatusBadRequest || !strings.Contains(message, tt.message) {
+				t.Fatalf("expected 400 with %q, got %d: %s", tt.message, code, message)
+			}
+		})
+	}
+}
+
+func TestConvertBatchLimitsInflatedWorkbooks(t *testing.T) {
+	sales := buildWorkbook(t, "Sales")
+	cfg := testConfig()
+	// The workbook is extracted, but opening it would inflate it past what
+	// is left of the limit.
+	cfg.MaxZipBytes = int64(len(sales)) + 64
+
+	code, resp, message := convertBatchRequest(t, cfg, testFile{"books.zip", zipArchive(t, testFile{"sales.xlsx", sales})})
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", code, message)
+	}
+	if len(resp.Workbooks) != 1 || resp.Workbooks[0].OK || resp.Workbooks[0].Error == "" {
+		t.Fatalf("expected the workbook to fail, got %+v", resp.Workbooks)
+	}
+
+	cfg.MaxZipBytes = 1 << 20
+	code, resp, message = convertBatchRequest(t, cfg, testFile{"books.zip", zipArchive(t, testFile{"sales.xlsx", sales})})
+	if code != http.StatusOK || len(resp.Workbooks) != 1 || !resp.Workbooks[0].OK {
+		t.Fatalf("expected the workbook to convert, got %d %s %+v", code, message, resp.Workbooks)
+	}
+}
+
+func TestConvertBatchSharesInflationBudget(t *testing.T) {
+	sales := buildWorkbook(t, "Sales")
+	inflated := inflatedSize(sales)
+	archive := zipArchive(t, testFile{"a.xlsx", sales}, testFile{"b.xlsx", sales})
+
+	tests := []struct {
+		name   string
+		budget int64
+		files  []testFile
+		// ok lists whether each workbook is expected to convert.
+		ok []bool
+	}{
+		{"direct uploads within the budget", 2*inflated + 64,
+			[]testFile{{"a.xlsx", sales}, {"b.xlsx", sales}}, []bool{true, true}},
+		{"direct uploads past the budget", inflated * 3 / 2,
+			[]testFile{{"a.xlsx", sales}, {"b.xlsx", sales}, {"c.xlsx", sales}}, []bool{true, false, false}},
+		{"archive within the budget", 2*int64(len(sales)) + 2*inflated + 64,
+			[]testFile{{"books.zip", archive}}, []bool{true, true}},
+		{"archive past the budget", 2*int64(len(sales)) + inflated*3/2,
+			[]testFile{{"books.zip", archive}}, []bool{true, false}},
+		{"archive and direct upload", 2*int64(len(sales)) + 2*inflated + 64,
+			[]testFile{{"books.zip", archive}, {"c.xlsx", sales}}, []bool{true, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.MaxZipBytes = tt.budget
+			code, resp, message := convertBatchRequest(t, cfg, tt.files...)
+			if code != http.StatusOK || len(resp.Workbooks) != len(tt.ok) {
+				t.Fatalf("unexpected response %d %s: %+v", code, message, resp.Workbooks)
+			}
+			for i, ok := range tt.ok {
+				if resp.Workbooks[i].OK != ok {
+					t.Fatalf("workbook %d: expected ok=%v, got %+v", i, ok, resp.Workbooks[i])
+				}
+			}
+		})
+	}
+
+	cfg := testConfig()
+	cfg.MaxZipBytes = inflated / 2
+	w := serve(convertHandler(cfg, nil), uploadRequest(t, "/api/convert", testFile{"a.xlsx", sales}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a single workbook past the limit to fail, got %d: %s", w.Code, w.Body.String())
+	}
+}
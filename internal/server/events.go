@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"excellent-md/internal/config"
+	"excellent-md/internal/storage"
+	"excellent-md/pkg/xlsxmd"
+)
+
+// eventsHandler converts an upload like /api/convert but answers with a
+// Server-Sent Events stream: a "progress" event as each sheet starts,
+// finishes, fails or is skipped, then one "result" event carrying the
+// /api/convert response, or an "error" event.
+func eventsHandler(cfg config.Config, store storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestsTotal.Add(1)
+		start := time.Now()
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		in, err := readConvertRequest(cfg, w, r)
+		if err != nil {
+			conversionErrors.Add(1)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.ConversionTimeout)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher := http.NewResponseController(w)
+		_ = flusher.Flush()
+
+		events := make(chan xlsxmd.ProgressEvent, 16)
+		var result xlsxmd.Result
+		go func() {
+			defer close(events)
+			result, err = convertUpload(ctx, store, in, start, xlsxmd.WithProgress(func(event xlsxmd.ProgressEvent) {
+				events <- event
+			}))
+		}()
+		for event := range events {
+			writeEvent(w, "progress", event)
+			_ = flusher.Flush()
+		}
+
+		if err != nil {
+			writeEvent(w, "error", struct {
+				apiError
+				Status int `json:"status"`
+			}{apiError{OK: false, Error: err.Error()}, conversionStatus(err)})
+		} else {
+			writeEvent(w, "result", apiResponse{OK: true, Result: result})
+		}
+		_ = flusher.Flush()
+	}
+}
+
+// writeEvent writes one Server-Sent Event with payload encoded as JSON on a
+// single data line.
+func writeEvent(w io.Writer, name string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"excellent-md/pkg/xlsxmd"
+)
+
+// sseEvent is one parsed Server-Sent Event.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// parseEvents splits a text/event-stream body into events, failing on lines
+// that are not part of the "event:"/"data:" framing the handler writes.
+func parseEvents(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	events := []sseEvent{}
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.name == "" || current.data == "" {
+				t.Fatalf("incomplete event %+v in:\n%s", current, body)
+			}
+			events = append(events, current)
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		default:
+			t.Fatalf("unexpected line %q in:\n%s", line, body)
+		}
+	}
+	if current != (sseEvent{}) {
+		t.Fatalf("unterminated event %+v", current)
+	}
+	return events
+}
+
+// finalEvent checks that every event but the last is a progress event and
+// returns the last one.
+func finalEvent(t *testing.T, events []sseEvent) sseEvent {
+	t.Helper()
+	if len(events) == 0 {
+		t.Fatalf("expected events")
+	}
+	for _, event := range events[:len(events)-1] {
+		if event.name != "progress" {
+			t.Fatalf("expected only progress events before the last, got %q", event.name)
+		}
+	}
+	last := events[len(events)-1]
+	if last.name != "result" && last.name != "error" {
+		t.Fatalf("expected a final result or error event, got %q", last.name)
+	}
+	return last
+}
+
+func TestEventsHandler(t *testing.T) {
+	handler := eventsHandler(testConfig(), nil)
+	workbook := testFile{"book.xlsx", buildWorkbook(t, "Sales", "Costs", "Notes")}
+
+	w := serve(handler, uploadRequest(t, "/api/convert/events?sheets=Sales,Notes", workbook))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	events := parseEvents(t, w.Body.String())
+	last := finalEvent(t, events)
+	if last.name != "result" {
+		t.Fatalf("expected a result event, got %q: %s", last.name, last.data)
+	}
+
+	types := map[xlsxmd.ProgressType][]string{}
+	for _, event := range events[:len(events)-1] {
+		var progress xlsxmd.ProgressEvent
+		if err := json.Unmarshal([]byte(event.data), &progress); err != nil {
+			t.Fatalf("invalid progress event %q: %v", event.data, err)
+		}
+		if progress.Total != 3 || progress.Index < 1 || progress.Index > 3 {
+			t.Fatalf("unexpected progress position: %+v", progress)
+		}
+		types[progress.Type] = append(types[progress.Type], progress.Sheet)
+	}
+	if len(types[xlsxmd.ProgressStarted]) != 2 || len(types[xlsxmd.ProgressFinished]) != 2 || strings.Join(types[xlsxmd.ProgressSkipped], ",") != "Costs" {
+		t.Fatalf("unexpected progress events: %v", types)
+	}
+
+	var result apiResponse
+	if err := json.Unmarshal([]byte(last.data), &result); err != nil {
+		t.Fatalf("invalid result event: %v", err)
+	}
+	if !result.OK || len(result.Sheets) != 2 || !strings.Contains(result.CombinedMarkdown, "## Notes") {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestEventsHandlerErrors(t *testing.T) {
+	workbook := testFile{"book.xlsx", buildWorkbook(t, "Sales")}
+
+	cfg := testConfig()
+	cfg.ConversionTimeout = time.Nanosecond
+	w := serve(eventsHandler(cfg, nil), uploadRequest(t, "/api/convert/events", workbook))
+	last := finalEvent(t, parseEvents(t, w.Body.String()))
+	var failure struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error"`
+		Status int    `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(last.data), &failure); err != nil {
+		t.Fatalf("invalid error event: %v", err)
+	}
+	if last.name != "error" || failure.OK || failure.Status != http.StatusRequestTimeout || failure.Error == "" {
+		t.Fatalf("expected a 408 error event, got %q: %s", last.name, last.data)
+	}
+
+	w = serve(eventsHandler(testConfig(), nil), uploadRequest(t, "/api/convert/events", testFile{"book.csv", []byte("a,b")}))
+	if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("expected a JSON 400 before the stream, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
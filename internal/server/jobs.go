@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"excellent-md/internal/config"
+	"excellent-md/internal/storage"
+	"excellent-md/pkg/xlsxmd"
+)
+
+// Job states reported by GET /api/jobs/{id}.
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// job is a conversion running in the background. Its fields are guarded by
+// the queue's mutex.
+type job struct {
+	id       string
+	upload   upload
+	status   string
+	created  time.Time
+	finished time.Time
+	total    int
+	skipped  int
+	done     int
+	current  string
+	result   xlsxmd.Result
+	err      error
+}
+
+// jobProgress is the progress of a job through the sheets of its workbook.
+// Skipped sheets are left out of the total.
+type jobProgress struct {
+	SheetsTotal  int    `json:"sheets_total"`
+	SheetsDone   int    `json:"sheets_done"`
+	CurrentSheet string `json:"current_sheet,omitempty"`
+}
+
+type jobResponse struct {
+	OK         bool        `json:"ok"`
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	Filename   string      `json:"filename"`
+	CreatedAt  time.Time   `json:"created_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	Progress   jobProgress `json:"progress"`
+	Error      string      `json:"error,omitempty"`
+	StatusURL  string      `json:"status_url"`
+	ResultURL  string      `json:"result_url"`
+}
+
+// jobQueue converts uploads on a fixed number of workers and keeps finished
+// jobs until their TTL expires. The uploads of queued and running jobs are
+// held in memory, at most cfg.MaxQueuedJobBytes of them together.
+type jobQueue struct {
+	cfg     config.Config
+	store   storage.Store
+	pending chan *job
+	ctx     context.Context
+	stop    context.CancelFunc
+
+	mu sync.Mutex
+	// held is the size of the uploads of unfinished jobs.
+	held int64
+	jobs map[string]*job
+}
+
+// newJobQueue returns a queue whose workers are not started yet.
+func newJobQueue(cfg config.Config, store storage.Store) *jobQueue {
+	ctx, stop := context.WithCancel(context.Background())
+	return &jobQueue{
+		cfg:     cfg,
+		store:   store,
+		pending: make(chan *job, cfg.JobQueueSize),
+		ctx:     ctx,
+		stop:    stop,
+		jobs:    map[string]*job{},
+	}
+}
+
+// start runs the workers and the expiry of finished jobs until Close.
+func (queue *jobQueue) start() {
+	for range max(queue.cfg.JobWorkers, 1) {
+		go queue.work()
+	}
+	go queue.expire()
+}
+
+// Close stops the workers and cancels running jobs.
+func (queue *jobQueue) Close() {
+	queue.stop()
+}
+
+// submit queues an upload and returns its job, or false when the queue is
+// full or holds too many bytes of uploads.
+func (queue *jobQueue) submit(in upload) (*job, bool) {
+	j := &job{id: newJobID(), upload: in, status: jobQueued, created: time.Now().UTC()}
+	size := int64(len(in.payload))
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if queue.held+size > queue.cfg.MaxQueuedJobBytes {
+		return nil, false
+	}
+	select {
+	case queue.pending <- j:
+	default:
+		return nil, false
+	}
+	queue.held += size
+	queue.jobs[j.id] = j
+	return j, true
+}
+
+func (queue *jobQueue) work() {
+	for {
+		select {
+		case <-queue.ctx.Done():
+			return
+		case j := <-queue.pending:
+			queue.run(j)
+		}
+	}
+}
+
+func (queue *jobQueue) run(j *job) {
+	start := time.Now()
+	queue.mu.Lock()
+	j.status = jobRunning
+	in := j.upload
+	queue.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(queue.ctx, queue.cfg.JobTimeout)
+	defer cancel()
+	progress := xlsxmd.WithProgress(func(event xlsxmd.ProgressEvent) {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		j.total = event.Total
+		switch event.Type {
+		case xlsxmd.ProgressStarted:
+			j.current = event.Sheet
+		case xlsxmd.ProgressSkipped:
+			j.skipped++
+		case xlsxmd.ProgressFinished, xlsxmd.ProgressError:
+			j.done++
+		}
+	})
+	result, err := convertUpload(ctx, queue.store, in, start, progress)
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	queue.held -= int64(len(j.upload.payload))
+	j.upload.payload = nil
+	j.finished = time.Now().UTC()
+	j.current = ""
+	j.result, j.err = result, err
+	j.status = jobDone
+	if err != nil {
+		j.status = jobFailed
+	}
+}
+
+// expire drops finished jobs older than the TTL.
+func (queue *jobQueue) expire() {
+	ticker := time.NewTicker(min(queue.cfg.JobTTL, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-queue.ctx.Done():
+			return
+		case now := <-ticker.C:
+			queue.mu.Lock()
+			for id, j := range queue.jobs {
+				if !j.finished.IsZero() && now.Sub(j.finished) > queue.cfg.JobTTL {
+					delete(queue.jobs, id)
+				}
+			}
+			queue.mu.Unlock()
+		}
+	}
+}
+
+// response describes j; the caller holds the queue's mutex.
+func (j *job) response() jobResponse {
+	resp := jobResponse{
+		OK:        true,
+		ID:        j.id,
+		Status:    j.status,
+		Filename:  j.upload.filename,
+		CreatedAt: j.created,
+		Progress:  jobProgress{SheetsTotal: j.total - j.skipped, SheetsDone: j.done, CurrentSheet: j.current},
+		StatusURL: "/api/jobs/" + j.id,
+		ResultURL: "/api/jobs/" + j.id + "/result",
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		resp.FinishedAt = &finished
+	}
+	if j.err != nil {
+		resp.Error = j.err.Error()
+	}
+	return resp
+}
+
+func newJobID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// createJobHandler accepts the same upload as /api/convert and queues it,
+// answering 202 with the job's status.
+func createJobHandler(cfg config.Config, queue *jobQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestsTotal.Add(1)
+		in, err := readConvertRequest(cfg, w, r)
+		if err != nil {
+			conversionErrors.Add(1)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		j, ok := queue.submit(in)
+		if !ok {
+			writeError(w, http.StatusServiceUnavailable, "Too many queued conversions. Try again later.")
+			return
+		}
+
+		queue.mu.Lock()
+		resp := j.response()
+		queue.mu.Unlock()
+		w.Header().Set("Location", resp.StatusURL)
+		writeJSON(w, http.StatusAccepted, resp)
+	}
+}
+
+// jobStatusHandler reports the status and progress of a job.
+func jobStatusHandler(queue *jobQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queue.mu.Lock()
+		j, ok := queue.jobs[r.PathValue("id")]
+		var resp jobResponse
+		if ok {
+			resp = j.response()
+		}
+		queue.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "Job not found. Finished jobs expire after a while.")
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// jobResultHandler returns the result of a finished job in the same shape as
+// /api/convert, or 409 while it is still queued or running.
+func jobResultHandler(queue *jobQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queue.mu.Lock()
+		j, ok := queue.jobs[r.PathValue("id")]
+		var status string
+		var result xlsxmd.Result
+		var err error
+		if ok {
+			status, result, err = j.status, j.result, j.err
+		}
+		queue.mu.Unlock()
+
+		switch {
+		case !ok:
+			writeError(w, http.StatusNotFound, "Job not found. Finished jobs expire after a while.")
+		case status == jobQueued || status == jobRunning:
+			writeError(w, http.StatusConflict, "Job is not finished yet.")
+		case err != nil:
+			writeError(w, conversionStatus(err), err.Error())
+		default:
+			writeJSON(w, http.StatusOK, apiResponse{OK: true, Result: result})
+		}
+	}
+}
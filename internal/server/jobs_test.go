@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jobMux serves the job endpoints of queue.
+func jobMux(queue *jobQueue) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/jobs", createJobHandler(queue.cfg, queue))
+	mux.HandleFunc("GET /api/jobs/{id}", jobStatusHandler(queue))
+	mux.HandleFunc("GET /api/jobs/{id}/result", jobResultHandler(queue))
+	return mux
+}
+
+// submitJob posts the upload and returns the job status it is answered with.
+func submitJob(t *testing.T, mux http.Handler, target string, file testFile) (int, jobResponse) {
+	t.Helper()
+	w := serve(mux, uploadRequest(t, target, file))
+	var resp jobResponse
+	if w.Code == http.StatusAccepted {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid job response: %v", err)
+		}
+		if w.Header().Get("Location") != resp.StatusURL {
+			t.Fatalf("expected Location %q, got %q", resp.StatusURL, w.Header().Get("Location"))
+		}
+	}
+	return w.Code, resp
+}
+
+// jobStatus fetches the status of a job; ok is false when it is not found.
+func jobStatus(t *testing.T, mux http.Handler, id string) (jobResponse, bool) {
+	t.Helper()
+	w := serve(mux, httptest.NewRequest(http.MethodGet, "/api/jobs/"+id, nil))
+	if w.Code == http.StatusNotFound {
+		return jobResponse{}, false
+	}
+	var resp jobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid job status %d: %v", w.Code, err)
+	}
+	return resp, true
+}
+
+// waitForJob polls until done reports true for the job's status, or fails
+// after a few seconds.
+func waitForJob(t *testing.T, mux http.Handler, id string, done func(jobResponse, bool) bool) jobResponse {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, ok := jobStatus(t, mux, id); done(resp, ok) {
+			return resp
+		}
+	}
+	t.Fatalf("job %s did not reach the expected state", id)
+	return jobResponse{}
+}
+
+func TestJobQueue(t *testing.T) {
+	cfg := testConfig()
+	cfg.JobQueueSize = 1
+	queue := newJobQueue(cfg, nil)
+	defer queue.Close()
+	mux := jobMux(queue)
+	workbook := testFile{"book.xlsx", buildWorkbook(t, "Sales", "Costs")}
+
+	code, queued := submitJob(t, mux, "/api/jobs?sheets=Costs", workbook)
+	if code != http.StatusAccepted || queued.Status != jobQueued || queued.Filename != "book.xlsx" {
+		t.Fatalf("unexpected job %d: %+v", code, queued)
+	}
+	if code, _ := submitJob(t, mux, "/api/jobs", workbook); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 from a full queue, got %d", code)
+	}
+	if w := serve(mux, httptest.NewRequest(http.MethodGet, queued.ResultURL, nil)); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a queued job, got %d", w.Code)
+	}
+	if w := serve(mux, httptest.NewRequest(http.MethodGet, "/api/jobs/missing/result", nil)); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown job, got %d", w.Code)
+	}
+
+	queue.start()
+	done := waitForJob(t, mux, queued.ID, func(resp jobResponse, ok bool) bool {
+		return ok && resp.Status != jobQueued && resp.Status != jobRunning
+	})
+	if done.Status != jobDone || done.FinishedAt == nil {
+		t.Fatalf("unexpected finished job: %+v", done)
+	}
+	if done.Progress != (jobProgress{SheetsTotal: 1, SheetsDone: 1}) {
+		t.Fatalf("unexpected progress: %+v", done.Progress)
+	}
+	w := serve(mux, httptest.NewRequest(http.MethodGet, queued.ResultURL, nil))
+	var result apiResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("unexpected result %d: %s", w.Code, w.Body.String())
+	}
+	if len(result.Sheets) != 1 || !strings.Contains(result.CombinedMarkdown, "## Costs") {
+		t.Fatalf("unexpected result: %+v", result.Result)
+	}
+
+	failed := testFile{"broken.xlsx", []byte("not a workbook")}
+	code, queued = submitJob(t, mux, "/api/jobs", failed)
+	if code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d", code)
+	}
+	done = waitForJob(t, mux, queued.ID, func(resp jobResponse, ok bool) bool {
+		return ok && resp.Status == jobFailed
+	})
+	if done.Error == "" {
+		t.Fatalf("expected the job error, got %+v", done)
+	}
+	if w := serve(mux, httptest.NewRequest(http.MethodGet, queued.ResultURL, nil)); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a failed job, got %d", w.Code)
+	}
+}
+
+func TestJobQueueLimitsHeldBytes(t *testing.T) {
+	cfg := testConfig()
+	workbook := testFile{"book.xlsx", buildWorkbook(t, "Sales")}
+	cfg.MaxQueuedJobBytes = int64(len(workbook.data)) * 3 / 2
+	queue := newJobQueue(cfg, nil)
+	defer queue.Close()
+	mux := jobMux(queue)
+
+	code, first := submitJob(t, mux, "/api/jobs", workbook)
+	if code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if code, _ := submitJob(t, mux, "/api/jobs", workbook); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 over the byte limit, got %d", code)
+	}
+
+	queue.start()
+	waitForJob(t, mux, first.ID, func(resp jobResponse, ok bool) bool { return resp.Status == jobDone })
+	if code, _ := submitJob(t, mux, "/api/jobs", workbook); code != http.StatusAccepted {
+		t.Fatalf("expected a finished job to release its upload, got %d", code)
+	}
+}
+
+func TestJobQueueExpiresFinishedJobs(t *testing.T) {
+	cfg := testConfig()
+	cfg.JobTTL = 20 * time.Millisecond
+	queue := newJobQueue(cfg, nil)
+	queue.start()
+	defer queue.Close()
+	mux := jobMux(queue)
+
+	code, queued := submitJob(t, mux, "/api/jobs", testFile{"book.xlsx", buildWorkbook(t, "Sales")})
+	if code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d", code)
+	}
+	waitForJob(t, mux, queued.ID, func(resp jobResponse, ok bool) bool { return !ok })
+	if w := serve(mux, httptest.NewRequest(http.MethodGet, queued.ResultURL, nil)); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an expired job, got %d", w.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"excellent-md/pkg/xlsxmd"
+)
+
+// Media types /api/convert can answer with.
+const (
+	mediaJSON     = "application/json"
+	mediaMarkdown = "text/markdown"
+	mediaCSV      = "text/csv"
+	mediaHTML     = "text/html"
+	mediaZip      = "application/zip"
+)
+
+// negotiate picks the response media type of /api/convert from the Accept
+// header: the supported type with the highest quality, the first one on
+// ties. Wildcards, unsupported types and a missing header get JSON.
+func negotiate(r *http.Request) string {
+	best, bestQuality := mediaJSON, 0.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			quality := 1.0
+			if q, ok := params["q"]; ok {
+				if quality, err = strconv.ParseFloat(q, 64); err != nil {
+					continue
+				}
+			}
+			switch mediaType {
+			case "*/*", "application/*":
+				mediaType = mediaJSON
+			case mediaJSON, mediaMarkdown, mediaCSV, mediaHTML, mediaZip:
+			default:
+				continue
+			}
+			if quality > bestQuality {
+				best, bestQuality = mediaType, quality
+			}
+		}
+	}
+	return best
+}
+
+// acceptFormat returns the option rendering the output format a media type
+// asks for, overriding the format parameter.
+func acceptFormat(mediaType string) []xlsxmd.Option {
+	switch mediaType {
+	case mediaCSV:
+		return []xlsxmd.Option{xlsxmd.WithFormat(xlsxmd.FormatCSV)}
+	case mediaHTML:
+		return []xlsxmd.Option{xlsxmd.WithFormat(xlsxmd.FormatHTML)}
+	}
+	return nil
+}
+
+// csvConflict explains why result cannot be sent as a single CSV document,
+// which holds one table, or returns "" when it can.
+func csvConflict(result xlsxmd.Result) string {
+	if len(result.Sheets) != 1 {
+		return fmt.Sprintf("CSV holds a single table, but %d sheets were converted. Select one with the sheets parameter.", len(result.Sheets))
+	}
+	if tables := len(result.Sheets[0].Tables); tables > 1 {
+		return fmt.Sprintf("CSV holds a single table, but sheet %q holds %d. Select one with the range parameter.", result.Sheets[0].Name, tables)
+	}
+	return ""
+}
+
+// writeDocument sends a rendered document instead of the JSON envelope.
+func writeDocument(w http.ResponseWriter, mediaType, document string) {
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(document))
+}
+
+// writeBundle sends result as a ZIP of per-sheet Markdown files, named
+// after the uploaded workbook.
+func writeBundle(w http.ResponseWriter, filename string, result xlsxmd.Result) {
+	var out bytes.Buffer
+	if err := xlsxmd.WriteBundle(&out, result); err != nil {
+		writeError(w, http.StatusInternalServerError, "Unable to build the ZIP bundle.")
+		return
+	}
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)) + ".zip"
+	w.Header().Set("Content-Type", mediaZip)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out.Bytes())
+}
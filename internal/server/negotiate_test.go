@@ -0,0 +1,230 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestConvertBundle(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetName("Sheet1", "Q1 Sales")
+	file.NewSheet("q1-sales")
+	file.NewSheet("README")
+	for _, sheet := range []string{"Q1 Sales", "q1-sales", "README"} {
+		file.SetSheetRow(sheet, "A1", &[]any{"Name", "Next"})
+		file.SetSheetRow(sheet, "A2", &[]any{sheet, "Go"})
+	}
+	file.SetCellHyperLink("Q1 Sales", "B2", "README!A1", "Location")
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	r := uploadRequest(t, "/api/convert?hyperlinks=true", testFile{"report.xlsx", buffer.Bytes()})
+	r.Header.Set("Accept", "application/zip")
+	w := serve(convertHandler(testConfig(), nil), r)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != mediaZip {
+		t.Fatalf("unexpected response %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename=report.zip` {
+		t.Fatalf("unexpected Content-Disposition %q", disposition)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := map[string]string{}
+	names := []string{}
+	for _, entry := range archive.File {
+		reader, err := entry.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", entry.Name, err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		files[entry.Name] = string(data)
+		names = append(names, entry.Name)
+	}
+	if expected := []string{"q1-sales.md", "q1-sales-2.md", "readme-2.md", "README.md", "manifest.json"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("got files %q, expected %q", names, expected)
+	}
+
+	if !strings.HasPrefix(files["q1-sales.md"], "## Q1 Sales\n") || !strings.Contains(files["q1-sales.md"], "[Go](readme-2.md#readme)") {
+		t.Fatalf("unexpected sheet file:\n%s", files["q1-sales.md"])
+	}
+	contents := "# Contents\n\n- [Q1 Sales](q1-sales.md)\n- [q1-sales](q1-sales-2.md)\n- [README](readme-2.md)\n\n## Q1 Sales\n"
+	if readme := files["README.md"]; !strings.HasPrefix(readme, contents) || !strings.Contains(readme, "[Go](#readme)") {
+		t.Fatalf("unexpected README:\n%s", readme)
+	}
+
+	var manifest struct {
+		Meta struct {
+			SheetCount int `json:"sheet_count"`
+		} `json:"meta"`
+		Sheets []struct {
+			Name     string `json:"name"`
+			File     string `json:"file"`
+			RowCount int    `json:"row_count"`
+		} `json:"sheets"`
+		Skipped []any `json:"skipped"`
+	}
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.Meta.SheetCount != 3 || len(manifest.Sheets) != 3 || manifest.Skipped == nil {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	for i, expected := range [][2]string{{"Q1 Sales", "q1-sales.md"}, {"q1-sales", "q1-sales-2.md"}, {"README", "readme-2.md"}} {
+		if sheet := manifest.Sheets[i]; sheet.Name != expected[0] || sheet.File != expected[1] || sheet.RowCount != 2 {
+			t.Fatalf("unexpected manifest sheet %d: %+v", i, sheet)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   []string
+		expected string
+	}{
+		{"missing", nil, mediaJSON},
+		{"single type", []string{"text/markdown"}, mediaMarkdown},
+		{"highest quality", []string{"text/csv;q=0.5, text/markdown;q=0.9"}, mediaMarkdown},
+		{"default quality", []string{"text/markdown;q=0.9, application/zip"}, mediaZip},
+		{"tie goes to the first", []string{"text/csv, text/markdown"}, mediaCSV},
+		{"tie with quality", []string{"text/html;q=0.5, text/csv;q=0.5"}, mediaHTML},
+		{"any type", []string{"*/*"}, mediaJSON},
+		{"application wildcard", []string{"application/*;q=0.8, text/markdown;q=0.5"}, mediaJSON},
+		{"wildcard loses to quality", []string{"*/*;q=0.1, text/html"}, mediaHTML},
+		{"unsupported wildcard", []string{"text/*, text/csv;q=0.1"}, mediaCSV},
+		{"unsupported type", []string{"image/png"}, mediaJSON},
+		{"zero quality", []string{"text/markdown;q=0"}, mediaJSON},
+		{"invalid quality", []string{"text/markdown;q=high, text/csv;q=0.1"}, mediaCSV},
+		{"several headers", []string{"text/csv;q=0.2", "text/html;q=0.4"}, mediaHTML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/convert", nil)
+			for _, accept := range tt.accept {
+				r.Header.Add("Accept", accept)
+			}
+			if got := negotiate(r); got != tt.expected {
+				t.Fatalf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertAccept(t *testing.T) {
+	single := buildWorkbook(t, "Sales")
+	several := buildWorkbook(t, "Sales", "Costs")
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Name", "Qty"})
+	file.SetSheetRow("Sheet1", "A2", &[]any{"Asha", 1})
+	file.SetSheetRow("Sheet1", "A5", &[]any{"Region", "Total"})
+	file.SetSheetRow("Sheet1", "A6", &[]any{"EU", 3})
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+	tables := buffer.Bytes()
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		files       []testFile
+		code        int
+		contentType string
+		body        string
+	}{
+		{"csv of one sheet", "/api/convert", "text/csv", []testFile{{"book.xlsx", single}},
+			http.StatusOK, "text/csv; charset=utf-8", "Name,Qty\nAsha,1\nBen,2\n"},
+		{"csv of several sheets", "/api/convert", "text/csv", []testFile{{"book.xlsx", several}},
+			http.StatusNotAcceptable, "application/json; charset=utf-8", "sheets parameter"},
+		{"csv of a selected sheet", "/api/convert?sheets=Costs", "text/csv", []testFile{{"book.xlsx", several}},
+			http.StatusOK, "text/csv; charset=utf-8", "Name,Qty\nAsha,1\nBen,2\n"},
+		{"csv of several tables", "/api/convert?detect_tables=true", "text/csv", []testFile{{"book.xlsx", tables}},
+			http.StatusNotAcceptable, "application/json; charset=utf-8", "range parameter"},
+		{"csv of a selected table", "/api/convert?range=A5:B6", "text/csv", []testFile{{"book.xlsx", tables}},
+			http.StatusOK, "text/csv; charset=utf-8", "Region,Total\nEU,3\n"},
+		{"html of several sheets", "/api/convert", "text/html", []testFile{{"book.xlsx", several}},
+			http.StatusOK, "text/html; charset=utf-8", "<table>"},
+		{"markdown", "/api/convert", "text/markdown", []testFile{{"book.xlsx", several}},
+			http.StatusOK, "text/markdown; charset=utf-8", "## Costs"},
+		{"batch as markdown", "/api/convert", "text/markdown", []testFile{{"a.xlsx", single}, {"b.xlsx", several}},
+			http.StatusOK, "text/markdown; charset=utf-8", "# b.xlsx"},
+		{"batch as csv", "/api/convert", "text/csv", []testFile{{"a.xlsx", single}, {"b.xlsx", single}},
+			http.StatusNotAcceptable, "application/json; charset=utf-8", "JSON or Markdown"},
+		{"batch as zip", "/api/convert", "application/zip", []testFile{{"books.zip", zipArchive(t, testFile{"a.xlsx", single})}},
+			http.StatusNotAcceptable, "application/json; charset=utf-8", "JSON or Markdown"},
+		{"upload error stays json", "/api/convert", "text/csv", []testFile{{"book.csv", []byte("a,b")}},
+			http.StatusBadRequest, "application/json; charset=utf-8", "Only .xlsx files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := uploadRequest(t, tt.target, tt.files...)
+			r.Header.Set("Accept", tt.accept)
+			w := serve(convertHandler(testConfig(), nil), r)
+			if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType {
+				t.Fatalf("got %d %q, expected %d %q: %s", w.Code, w.Header().Get("Content-Type"), tt.code, tt.contentType, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("expected %q in:\n%s", tt.body, w.Body.String())
+			}
+			if tt.code != http.StatusBadRequest && w.Header().Get("Vary") != "Accept" {
+				t.Fatalf("expected Vary: Accept, got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestConvertRawUpload(t *testing.T) {
+	workbook := buildWorkbook(t, "Sales", "Costs")
+	tests := []struct {
+		name        string
+		disposition string
+		filename    string
+	}{
+		{"with Content-Disposition", `attachment; filename="q1/report.xlsx"`, "report.zip"},
+		{"without Content-Disposition", "", "workbook.zip"},
+		{"invalid Content-Disposition", "attachment; filename=", "workbook.zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/convert?sheets=Costs", bytes.NewReader(workbook))
+			r.Header.Set("Content-Type", xlsxContentType)
+			r.Header.Set("Accept", "application/zip")
+			if tt.disposition != "" {
+				r.Header.Set("Content-Disposition", tt.disposition)
+			}
+			w := serve(convertHandler(testConfig(), nil), r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment; filename="+tt.filename {
+				t.Fatalf("got Content-Disposition %q, expected file %s", disposition, tt.filename)
+			}
+			archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+			if err != nil || archive.File[0].Name != "costs.md" {
+				t.Fatalf("expected only the selected sheet in the bundle, got %v", err)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/convert", bytes.NewReader(nil))
+	r.Header.Set("Content-Type", xlsxContentType)
+	if w := serve(convertHandler(testConfig(), nil), r); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Missing file upload") {
+		t.Fatalf("expected 400 for an empty body, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -7,15 +7,17 @@ import (
 	"expvar"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"excellent-md/internal/config"
-	"excellent-md/internal/convert"
 	"excellent-md/internal/storage"
+	"excellent-md/pkg/xlsxmd"
 	"excellent-md/web"
 )
 
@@ -33,18 +35,25 @@ type apiError struct {
 
 type apiResponse struct {
 	OK bool `json:"ok"`
-	convert.Result
+	xlsxmd.Result
 }
 
 // App holds the HTTP handler and optional resources.
 type App struct {
 	Handler http.Handler
 	store   storage.Store
+	jobs    *jobQueue
 }
 
 // Close releases any held resources.
 func (app *App) Close() error {
-	if app == nil || app.store == nil {
+	if app == nil {
+		return nil
+	}
+	if app.jobs != nil {
+		app.jobs.Close()
+	}
+	if app.store == nil {
 		return nil
 	}
 	return app.store.Close()
@@ -59,6 +68,13 @@ func New(cfg config.Config) (*App, error) {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/convert", convertHandler(cfg, store))
+	mux.HandleFunc("/api/convert/stream", streamHandler(cfg, store))
+	mux.HandleFunc("/api/convert/events", eventsHandler(cfg, store))
+	jobs := newJobQueue(cfg, store)
+	jobs.start()
+	mux.HandleFunc("POST /api/jobs", createJobHandler(cfg, jobs))
+	mux.HandleFunc("GET /api/jobs/{id}", jobStatusHandler(jobs))
+	mux.HandleFunc("GET /api/jobs/{id}/result", jobResultHandler(jobs))
 	mux.HandleFunc("/health", healthHandler)
 	if cfg.EnableDebugVars {
 		mux.Handle("/debug/vars", expvar.Handler())
@@ -69,7 +85,7 @@ func New(cfg config.Config) (*App, error) {
 
 	handler := loggingMiddleware(securityHeadersMiddleware(mux))
 
-	return &App{Handler: handler, store: store}, nil
+	return &App{Handler: handler, store: store, jobs: jobs}, nil
 }
 
 func healthHandler(w http.ResponseWriter, _ *http.Request) {
@@ -86,12 +102,232 @@ func convertHandler(cfg config.Config, store storage.Store) http.HandlerFunc {
 			return
 		}
 
-		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxUploadBytes)
-		if err := r.ParseMultipartForm(cfg.MaxUploadBytes); err != nil {
+		uploads, batch, err := readUploads(cfg, w, r)
+		if err != nil {
+			conversionErrors.Add(1)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		accept := negotiate(r)
+		w.Header().Set("Vary", "Accept")
+		if batch {
+			// The batch gets the conversion timeout once per workbook, in
+			// place of the server's write timeout sized for one.
+			deadline := time.Now().Add(time.Duration(len(uploads)) * cfg.ConversionTimeout)
+			_ = http.NewResponseController(w).SetWriteDeadline(deadline.Add(writeGrace))
+			ctx, cancel := context.WithDeadline(r.Context(), deadline)
+			defer cancel()
+			switch accept {
+			case mediaJSON:
+				writeJSON(w, http.StatusOK, convertBatch(ctx, cfg, store, uploads))
+			case mediaMarkdown:
+				writeDocument(w, accept, convertBatch(ctx, cfg, store, uploads).CombinedMarkdown)
+			default:
+				writeError(w, http.StatusNotAcceptable, "Batch uploads can only be returned as JSON or Markdown.")
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), cfg.ConversionTimeout)
+		defer cancel()
+
+		result, err := convertUpload(ctx, store, uploads[0], start, acceptFormat(accept)...)
+		if err != nil {
+			writeError(w, conversionStatus(err), err.Error())
+			return
+		}
+		switch accept {
+		case mediaZip:
+			writeBundle(w, uploads[0].filename, result)
+		case mediaMarkdown:
+			writeDocument(w, accept, result.CombinedMarkdown)
+		case mediaCSV:
+			if message := csvConflict(result); message != "" {
+				writeError(w, http.StatusNotAcceptable, message)
+				return
+			}
+			writeDocument(w, accept, result.CombinedOutput)
+		case mediaHTML:
+			writeDocument(w, accept, result.CombinedOutput)
+		default:
+			writeJSON(w, http.StatusOK, apiResponse{OK: true, Result: result})
+		}
+	}
+}
+
+// upload is a workbook posted for conversion with its options.
+type upload struct {
+	filename string
+	payload  []byte
+	options  []xlsxmd.Option
+}
+
+// readConvertRequest reads the single workbook upload and conversion
+// options of the endpoints that do not take batches. Its errors are
+// messages for the client.
+func readConvertRequest(cfg config.Config, w http.ResponseWriter, r *http.Request) (upload, error) {
+	uploads, batch, err := readUploads(cfg, w, r)
+	if err != nil {
+		return upload{}, err
+	}
+	if batch {
+		return upload{}, errors.New("Upload a single .xlsx file. Batches are only supported by /api/convert.")
+	}
+	return uploads[0], nil
+}
+
+// xlsxContentType is the media type of a workbook sent as the raw request
+// body.
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// readUploads reads the workbooks and conversion options of an upload:
+// either a workbook sent as the raw request body with the .xlsx content
+// type and options in the query, or a multipart form with one or more
+// "file" fields, each an .xlsx workbook or a .zip of them. batch reports
+// whether the request holds more than one file or an archive, and is
+// answered with per-workbook results. Its errors are messages for the
+// client.
+func readUploads(cfg config.Config, w http.ResponseWriter, r *http.Request) (uploads []upload, batch bool, err error) {
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxUploadBytes)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == xlsxContentType {
+		in, err := readRawUpload(cfg, r)
+		if err != nil {
+			return nil, false, err
+		}
+		uploads = []upload{in}
+		limitInflation(uploads, cfg.MaxZipBytes)
+		return uploads, false, nil
+	}
+	if err := r.ParseMultipartForm(cfg.MaxUploadBytes); err != nil {
+		return nil, false, errors.New("Unable to read upload. Make sure the file is under the size limit.")
+	}
+
+	options, err := requestOptions(cfg, r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		return nil, false, errors.New("Missing file upload.")
+	}
+	// One budget bounds what the request's archives extract to and then
+	// what all of its workbooks inflate to when opened.
+	budget := cfg.MaxZipBytes
+	for _, header := range headers {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".xlsx":
+			payload, err := readFileHeader(header, cfg.MaxUploadBytes)
+			if err != nil {
+				return nil, false, err
+			}
+			uploads = append(uploads, upload{filename: header.Filename, payload: payload, options: options})
+		case ".zip":
+			batch = true
+			payload, err := readFileHeader(header, cfg.MaxUploadBytes)
+			if err != nil {
+				return nil, false, err
+			}
+			workbooks, extracted, err := readZip(cfg, header.Filename, payload, options, budget)
+			if err != nil {
+				return nil, false, err
+			}
+			budget -= extracted
+			uploads = append(uploads, workbooks...)
+		default:
+			return nil, false, errors.New("Only .xlsx files, or .zip archives of them, are supported.")
+		}
+		if len(uploads) > cfg.MaxBatchFiles {
+			return nil, false, fmt.Errorf("Too many workbooks. Upload at most %d at once.", cfg.MaxBatchFiles)
+		}
+	}
+	limitInflation(uploads, budget)
+	return uploads, batch || len(headers) > 1, nil
+}
+
+// convertUpload converts in, records the conversion and updates the
+// metrics. start is when the request arrived.
+func convertUpload(ctx context.Context, store storage.Store, in upload, start time.Time, extra ...xlsxmd.Option) (xlsxmd.Result, error) {
+	result, err := xlsxmd.Convert(ctx, in.payload, append(in.options, extra...)...)
+	recordConversion(store, in.filename, result, time.Since(start).Milliseconds(), err)
+	if err != nil {
+		conversionErrors.Add(1)
+		return result, err
+	}
+
+	conversionsTotal.Add(1)
+	for _, sheet := range result.Sheets {
+		if sheet.Error != "" {
+			sheetErrorsTotal.Add(1)
+		}
+	}
+	return result, nil
+}
+
+// recordConversion stores the outcome of a conversion when storage is set up.
+func recordConversion(store storage.Store, filename string, result xlsxmd.Result, durationMs int64, err error) {
+	if store == nil {
+		return
+	}
+	record := buildRecord(filename, result, durationMs, err)
+	storeCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if recordErr := store.RecordConversion(storeCtx, record); recordErr != nil {
+		fmt.Printf("storage error: %v\n", recordErr)
+	}
+}
+
+// conversionStatus is the HTTP status for a failed conversion.
+func conversionStatus(err error) int {
+	if errors.Is(err, xlsxmd.ErrConversionTimeout) {
+		return http.StatusRequestTimeout
+	}
+	return http.StatusBadRequest
+}
+
+// streamFormMemory is how much of a streaming upload is kept in memory;
+// the rest is spooled to a temporary file.
+const streamFormMemory = 1 << 20
+
+// writeGrace is how long after a conversion deadline the response may
+// still be written, so a timed out conversion can report its error.
+const writeGrace = 5 * time.Second
+
+// streamHandler converts an upload to Markdown written to the response while
+// it is produced. The upload is spooled to disk instead of read into memory,
+// so it has its own, larger size limit, and its own timeout covering both
+// the upload and the conversion in place of the server's read and write
+// timeouts.
+func streamHandler(cfg config.Config, store storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestsTotal.Add(1)
+		start := time.Now()
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		deadline := start.Add(cfg.StreamTimeout)
+		// Writers that cannot set deadlines, such as test recorders, have
+		// no server timeouts to lift either.
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(deadline)
+		_ = rc.SetWriteDeadline(deadline.Add(writeGrace))
+
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxStreamUploadBytes)
+		if err := r.ParseMultipartForm(streamFormMemory); err != nil {
 			conversionErrors.Add(1)
 			writeError(w, http.StatusBadRequest, "Unable to read upload. Make sure the file is under the size limit.")
 			return
 		}
+		defer r.MultipartForm.RemoveAll()
+
+		options, err := requestOptions(cfg, r)
+		if err != nil {
+			conversionErrors.Add(1)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		file, header, err := r.FormFile("file")
 		if err != nil {
@@ -107,37 +343,20 @@ func convertHandler(cfg config.Config, store storage.Store) http.HandlerFunc {
 			return
 		}
 
-		payload, err := readUpload(file, cfg.MaxUploadBytes)
-		if err != nil {
-			conversionErrors.Add(1)
-			writeError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-
-		ctx, cancel := context.WithTimeout(r.Context(), cfg.ConversionTimeout)
+		ctx, cancel := context.WithDeadline(r.Context(), deadline)
 		defer cancel()
 
-		result, err := convert.Convert(ctx, payload, convert.Options{
-			IncludeHiddenSheets: cfg.IncludeHiddenSheets,
-			MaxSheets:           cfg.MaxSheets,
-			MaxCellsPerSheet:    cfg.MaxCellsPerSheet,
-		})
-		durationMs := time.Since(start).Milliseconds()
-		record := buildRecord(header.Filename, result, durationMs, err)
-		if store != nil {
-			storeCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			if recordErr := store.RecordConversion(storeCtx, record); recordErr != nil {
-				fmt.Printf("storage error: %v\n", recordErr)
-			}
-			cancel()
-		}
+		out := &markdownWriter{ResponseWriter: w}
+		result, err := xlsxmd.ConvertTo(ctx, file, header.Size, out, options...)
+		recordConversion(store, header.Filename, result, time.Since(start).Milliseconds(), err)
 		if err != nil {
 			conversionErrors.Add(1)
-			status := http.StatusBadRequest
-			if errors.Is(err, convert.ErrConversionTimeout) {
-				status = http.StatusRequestTimeout
+			if out.started {
+				// The status is already sent, so the error ends the document.
+				fmt.Fprintf(out, "\n> Error: %v\n", err)
+				return
 			}
-			writeError(w, status, err.Error())
+			writeError(w, conversionStatus(err), err.Error())
 			return
 		}
 
@@ -147,12 +366,205 @@ func convertHandler(cfg config.Config, store storage.Store) http.HandlerFunc {
 				sheetErrorsTotal.Add(1)
 			}
 		}
+		out.start()
+	}
+}
+
+// markdownWriter sends the Markdown response headers before the first write,
+// so errors found before any output can still be answered with JSON.
+type markdownWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *markdownWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *markdownWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.ResponseWriter.Write(p)
+}
+
+// requestOptions builds conversion options from the server config and the
+// optional form or query parameters of the request.
+func requestOptions(cfg config.Config, r *http.Request) ([]xlsxmd.Option, error) {
+	options := []xlsxmd.Option{
+		xlsxmd.WithHiddenSheets(cfg.IncludeHiddenSheets),
+		xlsxmd.WithMaxSheets(cfg.MaxSheets),
+		xlsxmd.WithMaxCellsPerSheet(cfg.MaxCellsPerSheet),
+		xlsxmd.WithConcurrency(cfg.SheetConcurrency),
+	}
+
+	format := r.FormValue("format")
+	if _, err := xlsxmd.RendererFor(format); err != nil {
+		return nil, fmt.Errorf("Unsupported format. Use one of: %s.", strings.Join(xlsxmd.Formats(), ", "))
+	}
+	options = append(options, xlsxmd.WithFormat(format))
+
+	if align := r.FormValue("align"); align != "" {
+		enabled := strings.EqualFold(align, "auto")
+		if parsed, err := strconv.ParseBool(align); err == nil {
+			enabled = parsed
+		} else if !enabled {
+			return nil, errors.New("Invalid align value. Use auto or a boolean.")
+		}
+		options = append(options, xlsxmd.WithAlignColumns(enabled))
+	}
+	if spec := r.FormValue("column_align"); spec != "" {
+		aligns, err := xlsxmd.ParseColumnAlignments(spec)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid column_align: %v.", err)
+		}
+		options = append(options, xlsxmd.WithColumnAlignments(aligns))
+	}
+	if values := r.FormValue("values"); values != "" {
+		options = append(options, xlsxmd.WithValues(xlsxmd.ValueMode(values)))
+	}
+	if mode := r.FormValue("formula_mode"); mode != "" {
+		options = append(options, xlsxmd.WithFormulaMode(xlsxmd.FormulaMode(mode)))
+	}
+	if strategy := r.FormValue("merge_strategy"); strategy != "" {
+		options = append(options, xlsxmd.WithMergeStrategy(xlsxmd.MergeStrategy(strategy)))
+	}
+	richText, err := boolParam(r, "rich_text")
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, xlsxmd.WithRichText(richText))
+	hyperlinks, err := boolParam(r, "hyperlinks")
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, xlsxmd.WithHyperlinks(hyperlinks))
+	if mode := r.FormValue("comments"); mode != "" {
+		options = append(options, xlsxmd.WithComments(xlsxmd.CommentMode(mode)))
+	}
+	if mode := r.FormValue("header"); mode != "" {
+		options = append(options, xlsxmd.WithHeaderMode(xlsxmd.HeaderMode(mode)))
+	}
+	detectTables, err := boolParam(r, "detect_tables")
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, xlsxmd.WithDetectTables(detectTables))
+	namedObjects, err := boolParam(r, "named_objects")
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, xlsxmd.WithNamedObjects(namedObjects))
+	if sheets := listParam(r, "sheets"); len(sheets) > 0 {
+		options = append(options, xlsxmd.WithSheets(sheets...))
+	}
+	if refs := repeatedParam(r, "range"); len(refs) > 0 {
+		options = append(options, xlsxmd.WithRange(refs...))
+	}
+	if columns := listParam(r, "columns"); len(columns) > 0 {
+		options = append(options, xlsxmd.WithColumns(columns...))
+	}
+	if columns := listParam(r, "exclude_columns"); len(columns) > 0 {
+		options = append(options, xlsxmd.WithExcludeColumns(columns...))
+	}
+	if mode := r.FormValue("hidden_rows_cols"); mode != "" {
+		options = append(options, xlsxmd.WithHiddenRowsCols(xlsxmd.HiddenMode(mode)))
+	}
+	if mode := r.FormValue("outline"); mode != "" {
+		options = append(options, xlsxmd.WithOutline(xlsxmd.OutlineMode(mode)))
+	}
+	if expr := r.FormValue("filter"); expr != "" {
+		options = append(options, xlsxmd.WithFilter(expr))
+	}
+	if expr := r.FormValue("sort"); expr != "" {
+		options = append(options, xlsxmd.WithSort(expr))
+	}
+
+	if err := xlsxmd.NewOptions(options...).Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid conversion options: %v.", err)
+	}
+	return options, nil
+}
+
+// listParam reads a form or query parameter given as a comma-separated list,
+// repeated, or both.
+func listParam(r *http.Request, name string) []string {
+	values := []string{}
+	for _, value := range r.Form[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// repeatedParam reads a form or query parameter that may be repeated, for
+// values such as sheet names that can contain commas.
+func repeatedParam(r *http.Request, name string) []string {
+	values := []string{}
+	for _, value := range r.Form[name] {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
 
-		writeJSON(w, http.StatusOK, apiResponse{OK: true, Result: result})
+// boolParam reads an optional boolean form or query parameter.
+func boolParam(r *http.Request, name string) (bool, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s value. Use true or false.", name)
 	}
+	return parsed, nil
 }
 
-func readUpload(file multipart.File, limit int64) ([]byte, error) {
+// readRawUpload reads a workbook sent as the raw request body. Its file
+// name comes from an optional Content-Disposition header.
+func readRawUpload(cfg config.Config, r *http.Request) (upload, error) {
+	if err := r.ParseForm(); err != nil {
+		return upload{}, errors.New("Unable to read query parameters.")
+	}
+	options, err := requestOptions(cfg, r)
+	if err != nil {
+		return upload{}, err
+	}
+
+	payload, err := readUpload(r.Body, cfg.MaxUploadBytes)
+	if err != nil {
+		return upload{}, errors.New("Unable to read upload. Make sure the file is under the size limit.")
+	}
+	if len(payload) == 0 {
+		return upload{}, errors.New("Missing file upload.")
+	}
+
+	filename := "workbook.xlsx"
+	if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		filename = filepath.Base(params["filename"])
+	}
+	return upload{filename: filename, payload: payload, options: options}, nil
+}
+
+func readFileHeader(header *multipart.FileHeader, limit int64) ([]byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload")
+	}
+	defer file.Close()
+	return readUpload(file, limit)
+}
+
+func readUpload(file io.Reader, limit int64) ([]byte, error) {
 	payload, err := io.ReadAll(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read upload")
@@ -214,7 +626,7 @@ func setupStore(cfg config.Config) (storage.Store, error) {
 	return store, nil
 }
 
-func buildRecord(filename string, result convert.Result, durationMs int64, err error) storage.ConversionRecord {
+func buildRecord(filename string, result xlsxmd.Result, durationMs int64, err error) storage.ConversionRecord {
 	record := storage.ConversionRecord{
 		Filename:   filename,
 		SheetCount: result.Meta.SheetCount,
@@ -247,3 +659,9 @@ func (w *statusWriter) WriteHeader(status int) {
 	w.status = status
 	w.ResponseWriter.WriteHeader(status)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer to flush
+// streamed responses.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
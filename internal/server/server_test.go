@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"excellent-md/internal/config"
+)
+
+// testConfig returns limits small enough for tests to reach.
+func testConfig() config.Config {
+	return config.Config{
+		MaxUploadBytes:       1 << 20,
+		MaxStreamUploadBytes: 1 << 20,
+		MaxSheets:            10,
+		MaxCellsPerSheet:     1000,
+		MaxBatchFiles:        5,
+		MaxZipEntries:        10,
+		MaxZipBytes:          1 << 20,
+		SheetConcurrency:     1,
+		ConversionTimeout:    10 * time.Second,
+		StreamTimeout:        10 * time.Second,
+		JobWorkers:           1,
+		JobQueueSize:         10,
+		MaxQueuedJobBytes:    1 << 20,
+		JobTimeout:           10 * time.Second,
+		JobTTL:               time.Hour,
+	}
+}
+
+// buildWorkbook returns an xlsx with the given sheets, each holding a header
+// and two data rows.
+func buildWorkbook(t *testing.T, sheets ...string) []byte {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	for i, sheet := range sheets {
+		if i == 0 {
+			file.SetSheetName("Sheet1", sheet)
+		} else if _, err := file.NewSheet(sheet); err != nil {
+			t.Fatalf("failed to build xlsx: %v", err)
+		}
+		file.SetSheetRow(sheet, "A1", &[]any{"Name", "Qty"})
+		file.SetSheetRow(sheet, "A2", &[]any{"Asha", 1})
+		file.SetSheetRow(sheet, "A3", &[]any{"Ben", 2})
+	}
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// testFile is a file field of a multipart upload.
+type testFile struct {
+	name string
+	data []byte
+}
+
+// uploadRequest returns a multipart POST to target with a "file" field per
+// file.
+func uploadRequest(t *testing.T, target string, files ...testFile) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, file := range files {
+		part, err := form.CreateFormFile("file", file.name)
+		if err != nil {
+			t.Fatalf("failed to build upload: %v", err)
+		}
+		part.Write(file.data)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatalf("failed to build upload: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, target, &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	return r
+}
+
+// serve runs r through handler and returns the recorded response.
+func serve(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
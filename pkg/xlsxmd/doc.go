@@ -0,0 +1,31 @@
+// Package xlsxmd converts XLSX workbooks to Markdown.
+//
+// It is the public, importable entry point to the converter used by the
+// Excellent-MD server and the xlsx2md command:
+//
+//	result, err := xlsxmd.Convert(ctx, data,
+//		xlsxmd.WithMaxSheets(50),
+//		xlsxmd.WithHiddenSheets(false),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(result.CombinedMarkdown)
+//
+// Each sheet is converted independently; a failing sheet reports its error
+// in SheetResult while the other sheets still convert. Convert itself fails
+// for unreadable workbooks (ErrInvalidFile), the sheet limit
+// (ErrTooManySheets), invalid options (ErrInvalidOption), unknown output
+// formats (ErrUnknownFormat) and context expiry (ErrConversionTimeout, or
+// the context's error when it is canceled). All of them can be matched with
+// errors.Is.
+//
+// # Compatibility
+//
+// The package follows semantic versioning within the module. Exported
+// functions, options, types and their JSON field names are not removed or
+// changed incompatibly; new options and result fields may be added. The
+// promise covers the Go API only: the generated Markdown and other output,
+// including warning texts, may change between releases as conversion
+// improves, and such changes are called out in the release notes.
+package xlsxmd
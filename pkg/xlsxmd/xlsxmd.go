@@ -0,0 +1,497 @@
+package xlsxmd
+
+import (
+	"context"
+	"io"
+
+	"excellent-md/internal/convert"
+)
+
+// Result is the top-level conversion result.
+type Result = convert.Result
+
+// Meta provides metadata about a conversion.
+type Meta = convert.Meta
+
+// SheetResult is the per-sheet output or error.
+type SheetResult = convert.SheetResult
+
+// SkippedSheet describes a sheet that was intentionally skipped.
+type SkippedSheet = convert.SkippedSheet
+
+// TableResult is one table found within a sheet with WithDetectTables or
+// WithNamedObjects.
+type TableResult = convert.TableResult
+
+// ProgressEvent reports a change in the state of one sheet during a
+// conversion started with WithProgress.
+type ProgressEvent = convert.ProgressEvent
+
+// ProgressType is the kind of a ProgressEvent.
+type ProgressType = convert.ProgressType
+
+// Progress event types.
+const (
+	ProgressStarted  = convert.ProgressStarted
+	ProgressFinished = convert.ProgressFinished
+	ProgressSkipped  = convert.ProgressSkipped
+	ProgressError    = convert.ProgressError
+)
+
+// Renderer turns extracted sheet rows into an output document. Implement it
+// to plug in a custom output format with WithRenderer.
+type Renderer = convert.Renderer
+
+// SheetMeta describes the sheet passed to a Renderer.
+type SheetMeta = convert.SheetMeta
+
+// Alignment is a Markdown table column alignment.
+type Alignment = convert.Alignment
+
+// Column alignments for WithColumnAlignments.
+const (
+	AlignNone   = convert.AlignNone
+	AlignLeft   = convert.AlignLeft
+	AlignRight  = convert.AlignRight
+	AlignCenter = convert.AlignCenter
+)
+
+// ValueMode selects formatted or raw cell values.
+type ValueMode = convert.ValueMode
+
+// Cell value modes for WithValues.
+const (
+	ValuesFormatted = convert.ValuesFormatted
+	ValuesRaw       = convert.ValuesRaw
+)
+
+// FormulaMode selects how formula cells are output.
+type FormulaMode = convert.FormulaMode
+
+// Formula modes for WithFormulaMode.
+const (
+	FormulaCached      = convert.FormulaCached
+	FormulaText        = convert.FormulaText
+	FormulaBoth        = convert.FormulaBoth
+	FormulaRecalculate = convert.FormulaRecalculate
+)
+
+// MergeStrategy selects how merged cell ranges are output.
+type MergeStrategy = convert.MergeStrategy
+
+// Merge strategies for WithMergeStrategy.
+const (
+	MergeTopLeft = convert.MergeTopLeft
+	MergeFill    = convert.MergeFill
+	MergeHTML    = convert.MergeHTML
+)
+
+// HiddenMode selects how hidden rows and columns are output.
+type HiddenMode = convert.HiddenMode
+
+// Hidden row and column modes for WithHiddenRowsCols.
+const (
+	HiddenInclude = convert.HiddenInclude
+	HiddenExclude = convert.HiddenExclude
+	HiddenMark    = convert.HiddenMark
+)
+
+// OutlineMode selects how row outline levels (grouped rows) are output.
+type OutlineMode = convert.OutlineMode
+
+// Outline modes for WithOutline.
+const (
+	OutlineNone   = convert.OutlineNone
+	OutlineIndent = convert.OutlineIndent
+	OutlineArrow  = convert.OutlineArrow
+	OutlineList   = convert.OutlineList
+)
+
+// CommentMode selects how cell comments are output.
+type CommentMode = convert.CommentMode
+
+// Comment modes for WithComments.
+const (
+	CommentNone      = convert.CommentNone
+	CommentFootnotes = convert.CommentFootnotes
+	CommentNotes     = convert.CommentNotes
+)
+
+// HeaderMode selects which rows become the table header.
+type HeaderMode = convert.HeaderMode
+
+// Header modes for WithHeaderMode. See also HeaderRow and HeaderRows.
+const (
+	HeaderFirstRow = convert.HeaderFirstRow
+	HeaderAuto     = convert.HeaderAuto
+	HeaderNone     = convert.HeaderNone
+)
+
+// Options is the full set of conversion settings. Most callers should use
+// the With* functions instead of building it directly.
+type Options = convert.Options
+
+// Errors returned by Convert. Sheet-level errors are also exposed through
+// SheetResult.Err.
+var (
+	ErrInvalidFile       = convert.ErrInvalidFile
+	ErrTooManySheets     = convert.ErrTooManySheets
+	ErrSheetTooLarge     = convert.ErrSheetTooLarge
+	ErrConversionTimeout = convert.ErrConversionTimeout
+	ErrUnknownFormat     = convert.ErrUnknownFormat
+	ErrInvalidOption     = convert.ErrInvalidOption
+)
+
+// Built-in output formats for WithFormat.
+const (
+	FormatMarkdown = convert.FormatMarkdown
+	FormatCSV      = convert.FormatCSV
+	FormatTSV      = convert.FormatTSV
+	FormatJSON     = convert.FormatJSON
+	FormatJSONL    = convert.FormatJSONL
+	FormatHTML     = convert.FormatHTML
+	FormatAsciiDoc = convert.FormatAsciiDoc
+)
+
+// Option configures a conversion.
+type Option func(*Options)
+
+// WithOptions replaces all settings with opts. Options given after it still
+// apply on top.
+func WithOptions(opts Options) Option {
+	return func(o *Options) {
+		*o = opts
+	}
+}
+
+// WithHiddenSheets controls whether hidden sheets are converted or skipped.
+func WithHiddenSheets(include bool) Option {
+	return func(o *Options) {
+		o.IncludeHiddenSheets = include
+	}
+}
+
+// WithHiddenRowsCols selects how hidden rows and columns of visible sheets
+// are output: like any other (HiddenInclude, the default), dropped with a
+// warning listing them (HiddenExclude), or marked with "(hidden)"
+// (HiddenMark).
+func WithHiddenRowsCols(mode HiddenMode) Option {
+	return func(o *Options) {
+		o.HiddenRowsCols = mode
+	}
+}
+
+// WithMaxSheets fails the conversion when the workbook has more than n
+// sheets. Zero disables the limit.
+func WithMaxSheets(n int) Option {
+	return func(o *Options) {
+		o.MaxSheets = n
+	}
+}
+
+// WithMaxCellsPerSheet fails a sheet when it holds more than n cells. Zero disables the limit.
+func WithMaxCellsPerSheet(n int) Option {
+	return func(o *Options) {
+		o.MaxCellsPerSheet = n
+	}
+}
+
+// WithConcurrency converts up to n sheets in parallel. Sheets keep workbook
+// order in the result. Zero or one converts them one after another.
+func WithConcurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// WithProgress calls fn as each sheet starts, finishes, fails or is
+// skipped. With WithConcurrency fn must be safe for concurrent use.
+func WithProgress(fn func(ProgressEvent)) Option {
+	return func(o *Options) {
+		o.Progress = fn
+	}
+}
+
+// WithTempDir sets the directory where large worksheets are unzipped, see
+// WithUnzipLimits. The system temporary directory is used by default.
+func WithTempDir(dir string) Option {
+	return func(o *Options) {
+		o.TempDir = dir
+	}
+}
+
+// WithUnzipLimits caps the bytes a workbook may inflate to when opened, and
+// the worksheet size above which it is unzipped to temporary files instead
+// of memory. Zero keeps the defaults of 16 GB and 16 MB.
+func WithUnzipLimits(total, xml int64) Option {
+	return func(o *Options) {
+		o.UnzipSizeLimit = total
+		o.UnzipXMLSizeLimit = xml
+	}
+}
+
+// WithFormat renders every sheet in a built-in format in addition to
+// Markdown. The output is stored in SheetResult.Output and
+// Result.CombinedOutput; CSV and TSV only combine a single sheet of one
+// table.
+func WithFormat(format string) Option {
+	return func(o *Options) {
+		o.Format = format
+	}
+}
+
+// WithRenderer renders every sheet with a custom renderer in addition to
+// Markdown. It takes precedence over WithFormat.
+func WithRenderer(renderer Renderer) Option {
+	return func(o *Options) {
+		o.Renderer = renderer
+	}
+}
+
+// WithAlignColumns detects column types and right-aligns numeric columns
+// and left-aligns text columns in Markdown tables.
+func WithAlignColumns(enabled bool) Option {
+	return func(o *Options) {
+		o.AlignColumns = enabled
+	}
+}
+
+// WithColumnAlignments forces the alignment of columns, keyed by header text
+// or column letter.
+func WithColumnAlignments(aligns map[string]Alignment) Option {
+	return func(o *Options) {
+		o.ColumnAlignments = aligns
+	}
+}
+
+// WithValues selects whether cells are rendered with their number format
+// (ValuesFormatted, the default) or as stored (ValuesRaw).
+func WithValues(mode ValueMode) Option {
+	return func(o *Options) {
+		o.Values = mode
+	}
+}
+
+// WithFormulaMode selects whether formula cells show their stored value
+// (FormulaCached, the default), the formula text, both, or a recalculated
+// value for formulas without a stored one.
+func WithFormulaMode(mode FormulaMode) Option {
+	return func(o *Options) {
+		o.FormulaMode = mode
+	}
+}
+
+// WithMergeStrategy selects whether merged ranges keep only their top-left
+// value (MergeTopLeft, the default), repeat it across the range, or render
+// the sheet as an HTML table with colspan and rowspan.
+func WithMergeStrategy(strategy MergeStrategy) Option {
+	return func(o *Options) {
+		o.MergeStrategy = strategy
+	}
+}
+
+// WithRichText maps bold, italic, strikethrough and monospace fonts to
+// inline Markdown inside table cells.
+func WithRichText(enabled bool) Option {
+	return func(o *Options) {
+		o.RichText = enabled
+	}
+}
+
+// WithHyperlinks outputs linked cells as Markdown links. Links to other
+// sheets point at that sheet's heading in the combined document.
+func WithHyperlinks(enabled bool) Option {
+	return func(o *Options) {
+		o.Hyperlinks = enabled
+	}
+}
+
+// WithComments outputs cell comments as footnotes on their cell
+// (CommentFootnotes) or as a "Notes" list under the sheet (CommentNotes).
+// Comments are dropped by default. They are only output as Markdown; with
+// another format Convert fails with ErrInvalidOption.
+func WithComments(mode CommentMode) Option {
+	return func(o *Options) {
+		o.Comments = mode
+	}
+}
+
+// WithHeaderMode selects the header row: the first row (HeaderFirstRow, the
+// default), auto-detection, a specific row, merged rows, or synthesized
+// column letters.
+func WithHeaderMode(mode HeaderMode) Option {
+	return func(o *Options) {
+		o.HeaderMode = mode
+	}
+}
+
+// WithDetectTables splits sheets holding several tables separated by blank
+// rows or columns into one table per block, each under a "### Sheet1!B3:F20"
+// heading and listed in SheetResult.Tables.
+func WithDetectTables(enabled bool) Option {
+	return func(o *Options) {
+		o.DetectTables = enabled
+	}
+}
+
+// WithNamedObjects converts only Excel Tables and defined names, each as its
+// own table named after it, and skips sheets without any. Table headers and
+// totals rows follow the table definition.
+func WithNamedObjects(enabled bool) Option {
+	return func(o *Options) {
+		o.NamedObjects = enabled
+	}
+}
+
+// WithSheets converts only the sheets matching one of selectors: a name, a
+// glob pattern such as "Q? 2024", or a 1-based position such as "3".
+func WithSheets(selectors ...string) Option {
+	return func(o *Options) {
+		o.Sheets = selectors
+	}
+}
+
+// WithRange restricts conversion to A1 areas such as "B3:F20" or "A:D".
+// Sheet-qualified ranges such as "Sales!B3:F20" and "Costs!B2:D5" each
+// apply to their own sheet, and select only the sheets they name unless a
+// range without a sheet name is given for the others.
+func WithRange(refs ...string) Option {
+	return func(o *Options) {
+		o.Ranges = refs
+	}
+}
+
+// WithColumns keeps only the columns with the given header names or column
+// letters.
+func WithColumns(names ...string) Option {
+	return func(o *Options) {
+		o.Columns = names
+	}
+}
+
+// WithExcludeColumns drops the columns with the given header names or
+// column letters.
+func WithExcludeColumns(names ...string) Option {
+	return func(o *Options) {
+		o.ExcludeColumns = names
+	}
+}
+
+// WithOutline renders row outline levels (grouped rows): by indenting the
+// first column with non-breaking spaces (OutlineIndent) or "↳ "
+// (OutlineArrow), or as a nested bulleted list instead of a Markdown table
+// (OutlineList). Outline levels are ignored by default.
+func WithOutline(mode OutlineMode) Option {
+	return func(o *Options) {
+		o.Outline = mode
+	}
+}
+
+// WithFilter keeps the data rows matching expr, e.g.
+// `Status = "Open" and Priority <= 2`. Columns are referred to by header
+// name, in brackets when they contain spaces ([Due Date]). Comparisons use
+// =, !=, <, <=, >, >= or contains and combine with and, or, not and
+// parentheses. Numbers compare numerically, everything else as
+// case-insensitive text.
+func WithFilter(expr string) Option {
+	return func(o *Options) {
+		o.Filter = expr
+	}
+}
+
+// WithSort orders data rows by comma-separated header names, each prefixed
+// with - for descending order, e.g. "-Updated, Priority".
+func WithSort(expr string) Option {
+	return func(o *Options) {
+		o.Sort = expr
+	}
+}
+
+// NewOptions applies opts to the zero Options value.
+func NewOptions(opts ...Option) Options {
+	options := Options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// Convert reads an XLSX workbook and returns Markdown for each sheet.
+func Convert(ctx context.Context, data []byte, opts ...Option) (Result, error) {
+	return convert.Convert(ctx, data, NewOptions(opts...))
+}
+
+// ConvertTo reads an XLSX workbook from r and writes the combined Markdown
+// document to w row by row. The compressed workbook is read into memory
+// while it is opened, but large worksheets are unzipped to temporary files,
+// so memory use follows the file size rather than the sheets' cells. Pass
+// an *os.File to have it opened by name. It supports sheet and range
+// selection, value modes, column alignments by name and the limits; other
+// options fail with ErrInvalidOption. Merged cells are flattened and
+// formulas output as stored values, with the same warnings as Convert. The
+// result carries no Markdown.
+func ConvertTo(ctx context.Context, r io.ReaderAt, size int64, w io.Writer, opts ...Option) (Result, error) {
+	return convert.ConvertTo(ctx, r, size, w, NewOptions(opts...))
+}
+
+// SheetToMarkdown renders rows as a Markdown table, treating the first row
+// as the header. It returns the table and its row and column counts.
+func SheetToMarkdown(rows [][]string) (string, int, int) {
+	return convert.SheetToMarkdown(rows)
+}
+
+// CombineMarkdown joins sheet results into one document with a "## Name"
+// heading per sheet, including warnings and errors.
+func CombineMarkdown(sheets []SheetResult) string {
+	return convert.CombineMarkdown(sheets)
+}
+
+// Slug returns a lowercase file-name-safe form of a sheet name, e.g.
+// "Q1 Sales (EU)" becomes "q1-sales-eu".
+func Slug(name string) string {
+	return convert.Slug(name)
+}
+
+// SheetFileNames returns a unique slug per sheet for writing one file per
+// sheet. Repeated slugs get a -2, -3 suffix, and slugs in reserved are never
+// returned.
+func SheetFileNames(sheets []SheetResult, reserved ...string) []string {
+	return convert.SheetFileNames(sheets, reserved...)
+}
+
+// WriteBundle writes result as a ZIP archive with one Markdown file per
+// sheet, a README.md with a table of contents and the combined document,
+// and a manifest.json of the metadata, warnings and skipped sheets.
+func WriteBundle(w io.Writer, result Result) error {
+	return convert.WriteBundle(w, result)
+}
+
+// Formats lists the built-in output formats.
+func Formats() []string {
+	return convert.Formats()
+}
+
+// RendererFor returns the built-in renderer for format, or an error wrapping
+// ErrUnknownFormat.
+func RendererFor(format string) (Renderer, error) {
+	return convert.RendererFor(format)
+}
+
+// ParseColumnAlignments parses "Price=right,B=center" for
+// WithColumnAlignments.
+func ParseColumnAlignments(spec string) (map[string]Alignment, error) {
+	return convert.ParseColumnAlignments(spec)
+}
+
+// HeaderRow returns the header mode using sheet row n as the header.
+func HeaderRow(n int) HeaderMode {
+	return convert.HeaderRow(n)
+}
+
+// HeaderRows returns the header mode merging sheet rows first to last into
+// one header, e.g. "Region / Q1".
+func HeaderRows(first, last int) HeaderMode {
+	return convert.HeaderRows(first, last)
+}
@@ -0,0 +1,42 @@
+package xlsxmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestConvertAppliesOptions(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetCellValue("Sheet1", "A1", "Name")
+	file.SetCellValue("Sheet1", "A2", "Asha")
+	file.NewSheet("Second")
+
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("failed to build xlsx: %v", err)
+	}
+
+	res, err := Convert(context.Background(), buffer.Bytes(), WithMaxSheets(5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(res.CombinedMarkdown, "| Asha |") {
+		t.Fatalf("unexpected markdown:\n%s", res.CombinedMarkdown)
+	}
+
+	_, err = Convert(context.Background(), buffer.Bytes(), WithMaxSheets(1))
+	if !errors.Is(err, ErrTooManySheets) {
+		t.Fatalf("expected ErrTooManySheets, got %v", err)
+	}
+}
+
+func TestNewOptionsOrder(t *testing.T) {
+	opts := NewOptions(WithMaxSheets(3), WithOptions(Options{MaxCellsPerSheet: 10}), WithHiddenSheets(true))
+	if opts.MaxSheets != 0 || opts.MaxCellsPerSheet != 10 || !opts.IncludeHiddenSheets {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
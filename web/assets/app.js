@@ -208,13 +208,17 @@ async function convertFile() {
   formData.append("file", currentFile);
 
   try {
-    const response = await fetch("/api/convert", {
+    const response = await fetch("/api/convert/events", {
       method: "POST",
       body: formData,
     });
 
-    const payload = await response.json();
-    if (!response.ok || !payload.ok) {
+    if (!response.ok) {
+      const failure = await response.json().catch(() => ({}));
+      throw new Error(failure.error || "Conversion failed.");
+    }
+    const payload = await readConversionEvents(response, showProgress);
+    if (!payload.ok) {
       throw new Error(payload.error || "Conversion failed.");
     }
 
@@ -230,6 +234,62 @@ async function convertFile() {
   }
 }
 
+// readConversionEvents reads the Server-Sent Events of /api/convert/events,
+// passing progress events to onProgress, and resolves with the result.
+async function readConversionEvents(response, onProgress) {
+  const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffer = "";
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) break;
+    buffer += value;
+    let boundary;
+    while ((boundary = buffer.indexOf("\n\n")) !== -1) {
+      const event = parseEvent(buffer.slice(0, boundary));
+      buffer = buffer.slice(boundary + 2);
+      if (event.name === "progress") {
+        onProgress(event.data);
+      } else if (event.name === "result") {
+        return event.data;
+      } else if (event.name === "error") {
+        throw new Error(event.data?.error || "Conversion failed.");
+      }
+    }
+  }
+  throw new Error("Conversion ended unexpectedly.");
+}
+
+function parseEvent(frame) {
+  let name = "message";
+  const data = [];
+  frame.split("\n").forEach((line) => {
+    if (line.startsWith("event:")) {
+      name = line.slice(6).trim();
+    } else if (line.startsWith("data:")) {
+      data.push(line.slice(5).trim());
+    }
+  });
+  return { name, data: data.length ? JSON.parse(data.join("\n")) : null };
+}
+
+function showProgress(event) {
+  const position = `sheet ${event.index} of ${event.total}`;
+  switch (event.type) {
+    case "started":
+      setStatus(`Converting ${event.sheet} (${position})…`, "progress");
+      break;
+    case "finished":
+      setStatus(`Converted ${event.sheet}: ${event.rows || 0} rows (${position}).`, "progress");
+      break;
+    case "skipped":
+      setStatus(`Skipped ${event.sheet}: ${event.reason} (${position}).`, "progress");
+      break;
+    case "error":
+      setStatus(`${event.sheet} failed: ${event.error} (${position}).`, "progress");
+      break;
+  }
+}
+
 function copyText(text, button) {
   if (!text) return;
   if (navigator.clipboard?.writeText) {